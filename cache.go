@@ -0,0 +1,132 @@
+package main
+
+import (
+	"FastDocumentationLanguage/fdl"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// The manifest of the previous build is the build cache. A generated file is kept if
+// the source of its document, the sources of all dependencies, the build settings and
+// the version of FDL are unchanged and the file still exists. Everything else is
+// regenerated, and files of the previous build that are no longer generated are removed.
+
+// buildSettings returns a hash of the options that change the generated files besides
+// the documents themselves. Outputs of a build with other settings are never reused.
+func buildSettings(setFlags options) string {
+	tags := append([]string(nil), setFlags.Tags...)
+	sort.Strings(tags)
+	var b strings.Builder
+	fmt.Fprintf(&b, "theme=%s\ntitle=%s\n", setFlags.Theme, setFlags.SiteTitle)
+	fmt.Fprintf(&b, "devdoc=%t\nnumbered=%t\n", setFlags.Devdoc, setFlags.Numbered)
+	fmt.Fprintf(&b, "vars=%s\ntags=%s\n", strings.Join(fdl.FormatVars(setFlags.Vars), ","), strings.Join(tags, ","))
+	return hashContent([]byte(b.String()))
+}
+
+// readManifest reads the manifest of the previous build from the output directory.
+// It returns nil if there is none.
+func readManifest(outputPath string) (*manifest, error) {
+	content, err := os.ReadFile(filepath.Join(outputPath, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("can't read the manifest of the previous build: %w", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("can't read the manifest of the previous build: %w", err)
+	}
+	return &m, nil
+}
+
+// buildCache decides which files of the previous build in outputPath can be kept.
+type buildCache struct {
+	outputPath string
+	// previous is the manifest of the previous build, nil if there is none.
+	previous *manifest
+	// rebuild disables the reuse of files, stale files are removed anyway.
+	rebuild bool
+}
+
+func (m *manifest) document(input string) *manifestDocument {
+	for i := range m.Documents {
+		if m.Documents[i].Input == input {
+			return &m.Documents[i]
+		}
+	}
+	return nil
+}
+
+// reusableOutput returns the entry of the previous build for file, if file was generated
+// from doc and can be kept. m is the manifest of the current build.
+func (c *buildCache) reusableOutput(m *manifest, doc *fdl.Document, file string) (manifestOutput, bool) {
+	if c.rebuild || c.previous == nil || c.previous.FDLVersion != m.FDLVersion || c.previous.Settings != m.Settings {
+		return manifestOutput{}, false
+	}
+	current, before := m.document(doc.Name), c.previous.document(doc.Name)
+	if current == nil || before == nil || current.Hash != before.Hash || !reflect.DeepEqual(current.Dependencies, before.Dependencies) {
+		return manifestOutput{}, false
+	}
+	for _, output := range before.Outputs {
+		if output.File != filepath.ToSlash(file) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(c.outputPath, filepath.FromSlash(output.File))); err != nil {
+			return manifestOutput{}, false
+		}
+		return output, true
+	}
+	return manifestOutput{}, false
+}
+
+// outputFiles returns the names of all files listed in m.
+func (m *manifest) outputFiles() map[string]bool {
+	files := make(map[string]bool)
+	for _, doc := range m.Documents {
+		for _, output := range doc.Outputs {
+			files[output.File] = true
+		}
+	}
+	for _, output := range m.Files {
+		files[output.File] = true
+	}
+	return files
+}
+
+// removeStaleOutputs deletes the files of the previous build that the current build with
+// manifest m no longer generates, e.g. because their source was deleted or a format was
+// disabled. Only files listed in the previous manifest are touched, and the folders
+// that became empty by that.
+func (c *buildCache) removeStaleOutputs(m *manifest, diags *fdl.Diagnostics) {
+	if c.previous == nil {
+		return
+	}
+	generated := m.outputFiles()
+	var stale []string
+	for file := range c.previous.outputFiles() {
+		if !generated[file] && filepath.IsLocal(filepath.FromSlash(file)) {
+			stale = append(stale, file)
+		}
+	}
+	sort.Strings(stale)
+	for _, file := range stale {
+		err := os.Remove(filepath.Join(c.outputPath, filepath.FromSlash(file)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			diags.Warnf(fdl.Pos{File: file}, fdl.CodeOutput, "can't remove the stale output: %v", err)
+			continue
+		}
+		for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+			// Folders that still contain files can't be removed.
+			if os.Remove(filepath.Join(c.outputPath, filepath.FromSlash(dir))) != nil {
+				break
+			}
+		}
+	}
+}
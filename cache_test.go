@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncrementalBuild(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+	write := func(name string, content string) {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("Could not write %s: %v", name, err)
+		}
+	}
+	read := func(name string) string {
+		content, err := os.ReadFile(filepath.Join("documentation", name))
+		if err != nil {
+			return ""
+		}
+		return string(content)
+	}
+	build := func(opts options) {
+		if diags := processFiles(opts); diags.HasErrors() {
+			t.Fatalf("Expected no errors, got %v", diags.Sorted())
+		}
+	}
+
+	write("guide.fdl", "@title Guide\n@ref api.fdl#setup\n")
+	write("api.fdl", "@title API\n@section Setup\n")
+	write("faq.fdl", "@title FAQ\n")
+	write("other.fdl", "@title Other\n")
+	opts := defaultOptions()
+	opts.Formats = []string{"html", "markdown"}
+	build(opts)
+	if err := os.WriteFile(filepath.Join("documentation", "notes.txt"), []byte("mine"), 0644); err != nil {
+		t.Fatalf("Could not write notes.txt: %v", err)
+	}
+
+	// Unveränderte Ausgaben werden nicht neu geschrieben, daher bleibt die Markierung stehen.
+	for _, file := range []string{"guide.html", "faq.html", "other.html"} {
+		write(filepath.Join("documentation", file), "kept")
+	}
+	write("api.fdl", "@title API\n@section Installation\n@section Setup\n")
+	write("faq.fdl", "@title FAQ\nMore questions\n")
+	if err := os.Remove("other.fdl"); err != nil {
+		t.Fatalf("Could not remove other.fdl: %v", err)
+	}
+	opts.Formats = []string{"html"}
+	build(opts)
+
+	tests := []struct {
+		file        string
+		regenerated bool
+		exists      bool
+	}{
+		{"guide.html", true, true}, // depends on api.fdl
+		{"api.html", true, true},
+		{"faq.html", true, true},
+		{"other.html", false, false}, // source was deleted
+		{"guide.md", false, false},   // format was disabled
+		{"notes.txt", false, true},   // not generated by FDL
+	}
+	for _, tt := range tests {
+		content := read(tt.file)
+		if (content != "") != tt.exists {
+			t.Errorf("Expected %s to exist: %t", tt.file, tt.exists)
+		}
+		if tt.exists && (content != "kept" && content != "mine") != tt.regenerated {
+			t.Errorf("Expected %s to be regenerated: %t", tt.file, tt.regenerated)
+		}
+	}
+
+	write(filepath.Join("documentation", "faq.html"), "kept")
+	build(opts)
+	if read("faq.html") != "kept" {
+		t.Errorf("Expected unchanged faq.html to be skipped")
+	}
+	opts.Rebuild = true
+	build(opts)
+	if read("faq.html") == "kept" {
+		t.Errorf("Expected --rebuild to regenerate faq.html")
+	}
+	opts.Rebuild = false
+	write(filepath.Join("documentation", "faq.html"), "kept")
+	opts.Theme = "dark"
+	build(opts)
+	if read("faq.html") == "kept" {
+		t.Errorf("Expected a new theme to regenerate faq.html")
+	}
+}
+
+func TestIncludedFragmentsAreRebuilt(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"guide.fdl":    "@title Guide\n@include _contact.fdl",
+		"_contact.fdl": "Old address",
+	})
+	opts := defaultOptions()
+	if diags := processFiles(opts); diags.HasErrors() {
+		t.Fatalf("Expected no errors, got %v", diags.Sorted())
+	}
+	if _, err := os.Stat(filepath.Join("documentation", "_contact.html")); err == nil {
+		t.Errorf("Expected the fragment not to be converted on its own")
+	}
+
+	writeFiles(t, map[string]string{"_contact.fdl": "New address"})
+	if diags := processFiles(opts); diags.HasErrors() {
+		t.Fatalf("Expected no errors, got %v", diags.Sorted())
+	}
+	content, err := os.ReadFile(filepath.Join("documentation", "guide.html"))
+	if err != nil || !strings.Contains(string(content), "New address") {
+		t.Errorf("Expected guide.html to be rebuilt with the new fragment, got %s", content)
+	}
+}
+
+func TestReferencedFragmentsAreRebuilt(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"a.fdl":     "@title A\n@ref b.fdl#setup",
+		"b.fdl":     "@title B\n@include _frag.fdl",
+		"_frag.fdl": "@section Setup",
+	})
+	opts := defaultOptions()
+	if diags := processFiles(opts); diags.HasErrors() {
+		t.Fatalf("Expected no errors, got %v", diags.Sorted())
+	}
+
+	// Das Ziel des Verweises ändert sich nur im eingebundenen Fragment.
+	writeFiles(t, map[string]string{"_frag.fdl": "@section Setup!"})
+	if diags := processFiles(opts); diags.HasErrors() {
+		t.Fatalf("Expected no errors, got %v", diags.Sorted())
+	}
+	content, err := os.ReadFile(filepath.Join("documentation", "a.html"))
+	if err != nil || !strings.Contains(string(content), ">Setup!</a>") {
+		t.Errorf("Expected a.html to be rebuilt with the new link text, got %s", content)
+	}
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"FastDocumentationLanguage/fdl"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// version is the FDL release, printed by "fdl version".
+const version = "1.0.0"
+
+// Exit codes of the fdl binary.
+const (
+	exitOK       = 0
+	exitFindings = 1
+	exitUsage    = 2
+)
+
+// options are the settings shared by the subcommands. They are read from the
+// configuration file first and then overridden by the command line.
+type options struct {
+	FileExtension string
+	Directory     string
+	Devdoc        bool
+	// Formats are the enabled output backends, see renderers.
+	Formats  []string
+	Combined bool
+	// Numbered prefixes every section heading with its number, e.g. 2.1.3.
+	Numbered bool
+	// Rebuild regenerates all files, even if the previous build is still up to date.
+	Rebuild bool
+	// Clean deletes everything in the output directory before the build. Only "fdl build"
+	// has this option.
+	Clean bool
+	// Jobs is the number of documents that are parsed and rendered at the same time.
+	Jobs   int
+	Report string
+	// Inputs are the directories searched for documents, relative to the working directory.
+	Inputs []string
+	// Exclude are glob patterns of files and directories below the inputs that are
+	// skipped, Include the patterns a document must match if any are set. Both are
+	// relative to the working directory, see fileFilter.
+	Exclude   []string
+	Include   []string
+	Theme     string
+	SiteTitle string
+	// Vars and Tags select the branches of @if blocks.
+	Vars map[string]string
+	Tags []string
+	// AllBranches checks the markup of the @if branches that Vars and Tags leave out as
+	// well. Only "fdl lint" sets it.
+	AllBranches bool
+}
+
+// documentOptions returns the options the document at path is read with.
+func (o options) documentOptions(path string, diags *fdl.Diagnostics) fdl.Options {
+	return fdl.Options{Path: path, Vars: o.Vars, Tags: o.Tags, Draft: o.Devdoc, Numbered: o.Numbered, AllBranches: o.AllBranches, Diagnostics: diags}
+}
+
+func defaultOptions() options {
+	return options{
+		FileExtension: ".fdl",
+		Directory:     "documentation",
+		Devdoc:        false,
+		Formats:       []string{"html"},
+		Inputs:        []string{"."},
+		Theme:         "default",
+		SiteTitle:     "Documentation",
+		Jobs:          runtime.NumCPU(),
+	}
+}
+
+// command is a subcommand of the fdl binary.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer, stderr io.Writer) int
+}
+
+var commands []command
+
+func init() {
+	// Assigned in init because "help" refers back to the command list.
+	commands = []command{
+		{"build", "Convert all documents (default command)", runBuild},
+		{"render", "Convert a single document, or standard input, to standard output or a file", runRender},
+		{"lint", "Check all documents without generating output", runLintCommand},
+		{"serve", "Build the documentation and serve it over HTTP", runServe},
+		{"watch", "Rebuild the documentation whenever a document changes", runWatch},
+		{"config", "Print the effective configuration", runConfig},
+		{"init", "Create a starter document in the working directory", runInit},
+		{"version", "Print the FDL version", runVersion},
+		{"help", "Show help for a command", runHelp},
+	}
+}
+
+// run executes the command line args (without the program name) and returns the exit code.
+// Without a command, or if the first argument is an option, the documentation is built.
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && !isHelpFlag(args[0])) {
+		return runBuild(args, stdout, stderr)
+	}
+	if isHelpFlag(args[0]) {
+		printUsage(stdout)
+		return exitOK
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:], stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "fdl: unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return exitUsage
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: fdl <command> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "fdl help <command>" for the options of a command.`)
+}
+
+// newFlagSet creates the flag set of a command. Errors and usage go to stderr.
+func newFlagSet(name string, usage string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: fdl %s\n\nOptions:\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// stringFlag defines a string option with a long name and a short alias.
+func stringFlag(fs *flag.FlagSet, p *string, long string, short string, usage string) {
+	fs.StringVar(p, long, *p, usage)
+	if short != "" {
+		fs.StringVar(p, short, *p, "shorthand for --"+long)
+	}
+}
+
+// intFlag defines an integer option with a long name and a short alias.
+func intFlag(fs *flag.FlagSet, p *int, long string, short string, usage string) {
+	fs.IntVar(p, long, *p, usage)
+	if short != "" {
+		fs.IntVar(p, short, *p, "shorthand for --"+long)
+	}
+}
+
+// boolFlag defines a boolean option with a long name and a short alias.
+func boolFlag(fs *flag.FlagSet, p *bool, long string, short string, usage string) {
+	fs.BoolVar(p, long, *p, usage)
+	if short != "" {
+		fs.BoolVar(p, short, *p, "shorthand for --"+long)
+	}
+}
+
+// listValue is a comma separated list option. Setting it replaces the whole list.
+type listValue struct {
+	p *[]string
+}
+
+func (v listValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return strings.Join(*v.p, ",")
+}
+
+func (v listValue) Set(s string) error {
+	*v.p = nil
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*v.p = append(*v.p, item)
+		}
+	}
+	return nil
+}
+
+// listFlag defines a list option with a long name and a short alias.
+func listFlag(fs *flag.FlagSet, p *[]string, long string, short string, usage string) {
+	fs.Var(listValue{p}, long, usage)
+	if short != "" {
+		fs.Var(listValue{p}, short, "shorthand for --"+long)
+	}
+}
+
+// varsValue is a "key=value" option that may be repeated. Every assignment
+// overrides only its own variable.
+type varsValue struct {
+	p *map[string]string
+}
+
+func (v varsValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return strings.Join(fdl.FormatVars(*v.p), ",")
+}
+
+func (v varsValue) Set(s string) error {
+	key, value, err := fdl.ParseVar(s)
+	if err != nil {
+		return err
+	}
+	vars := make(map[string]string, len(*v.p)+1)
+	for k, old := range *v.p {
+		vars[k] = old
+	}
+	vars[key] = value
+	*v.p = vars
+	return nil
+}
+
+// addInputFlags defines the options that select the documents and their content.
+func addInputFlags(fs *flag.FlagSet, opts *options) {
+	stringFlag(fs, &opts.FileExtension, "file-extension", "fe", "file extension of the documents")
+	listFlag(fs, &opts.Inputs, "input", "in", "comma separated directories searched for documents")
+	listFlag(fs, &opts.Exclude, "exclude", "", "comma separated glob patterns of files and directories to skip, e.g. docs/**/drafts")
+	listFlag(fs, &opts.Include, "include", "", "comma separated glob patterns, only matching documents are converted, e.g. docs/**/*.fdl")
+	intFlag(fs, &opts.Jobs, "jobs", "j", "number of documents processed in parallel")
+	fs.Var(varsValue{&opts.Vars}, "var", "set a variable for @if conditions, e.g. --var audience=internal (repeatable)")
+	listFlag(fs, &opts.Tags, "tag", "", "comma separated tags for @if conditions")
+}
+
+func addBuildFlags(fs *flag.FlagSet, opts *options) {
+	addInputFlags(fs, opts)
+	stringFlag(fs, &opts.Directory, "directory", "dir", "output directory, absolute or relative to the working directory")
+	listFlag(fs, &opts.Formats, "format", "fmt", "comma separated output formats: "+strings.Join(fdl.RendererNames(), ", "))
+	boolFlag(fs, &opts.Combined, "combined-output", "combined", "additionally combine all documents into one file")
+	boolFlag(fs, &opts.Numbered, "number-sections", "", "number sections and subsections, e.g. 2.1.3")
+	boolFlag(fs, &opts.Devdoc, "development-documentation", "dev-doc", "include @todo, @tbc and @internal content and mark the output as draft")
+	boolFlag(fs, &opts.Rebuild, "rebuild", "", "regenerate all files instead of only the changed ones")
+	stringFlag(fs, &opts.Theme, "theme", "", "HTML theme: "+strings.Join(fdl.ThemeNames(), ", "))
+	stringFlag(fs, &opts.SiteTitle, "title", "", "title of the index page")
+}
+
+// flagError is an error of fs.Parse. The flag package has already printed it together
+// with the usage of the command.
+type flagError struct {
+	error
+}
+
+func (e flagError) Unwrap() error {
+	return e.error
+}
+
+// parseArgs parses args into fs like fs.Parse and marks its errors as printed.
+func parseArgs(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return flagError{err}
+	}
+	return nil
+}
+
+// parseFlags parses args into fs and rejects positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return nil
+}
+
+// parseBuildFlags parses and validates the options of "fdl build".
+func parseBuildFlags(args []string, stderr io.Writer) (options, error) {
+	clean := false
+	opts, err := parseOptions("build", args, stderr, func(fs *flag.FlagSet) {
+		boolFlag(fs, &clean, "clean", "", "delete everything in the output directory before the build")
+	})
+	opts.Clean = clean
+	return opts, err
+}
+
+// parseOptions reads the configuration file and overrides it with the build options
+// in args. extra defines additional options of the command.
+func parseOptions(name string, args []string, stderr io.Writer, extra func(fs *flag.FlagSet)) (options, error) {
+	opts := defaultOptions()
+	if _, err := loadConfig(&opts); err != nil {
+		return opts, err
+	}
+	fs := newFlagSet(name, name+" [options]", stderr)
+	addBuildFlags(fs, &opts)
+	if extra != nil {
+		extra(fs)
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return opts, err
+	}
+	return opts, validateOptions(opts)
+}
+
+func validateOptions(opts options) error {
+	if opts.FileExtension == "" {
+		return errors.New("--file-extension must not be empty")
+	}
+	if opts.Directory == "" {
+		return errors.New("--directory must not be empty")
+	}
+	if opts.Jobs < 1 {
+		return errors.New("--jobs must be at least 1")
+	}
+	if len(opts.Inputs) == 0 {
+		return errors.New("--input must name at least one directory")
+	}
+	if len(opts.Formats) == 0 {
+		return errors.New("--format must name at least one output format")
+	}
+	for _, format := range opts.Formats {
+		if _, err := fdl.NewRenderer(format, fdl.Settings{}); err != nil {
+			return err
+		}
+	}
+	if !slices.Contains(fdl.ThemeNames(), opts.Theme) {
+		return fmt.Errorf("unknown theme %q (available: %s)", opts.Theme, strings.Join(fdl.ThemeNames(), ", "))
+	}
+	return nil
+}
+
+// usageError prints err, unless the flag package already did so, and returns the exit code.
+func usageError(err error, command string, stderr io.Writer) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if errors.As(err, new(flagError)) {
+		return exitUsage
+	}
+	fmt.Fprintf(stderr, "fdl %s: %v\nRun \"fdl help %s\" for usage.\n", command, err, command)
+	return exitUsage
+}
+
+func runBuild(args []string, stdout io.Writer, stderr io.Writer) int {
+	opts, err := parseBuildFlags(args, stderr)
+	if err != nil {
+		return usageError(err, "build", stderr)
+	}
+	createAsciiBanner()
+	return reportDiagnostics(processFiles(opts), stderr)
+}
+
+// reportDiagnostics prints diags and returns the exit code of a build.
+func reportDiagnostics(diags *fdl.Diagnostics, stderr io.Writer) int {
+	diags.Print(stderr)
+	if diags.HasErrors() {
+		return exitFindings
+	}
+	return exitOK
+}
+
+// reportFormats are the values of "fdl lint --report". Without it the report is text.
+var reportFormats = []string{"text", "json"}
+
+func runLintCommand(args []string, stdout io.Writer, stderr io.Writer) int {
+	opts := defaultOptions()
+	if _, err := loadConfig(&opts); err != nil {
+		return usageError(err, "lint", stderr)
+	}
+	fs := newFlagSet("lint", "lint [options]", stderr)
+	addInputFlags(fs, &opts)
+	stringFlag(fs, &opts.Report, "report", "", "report format: "+strings.Join(reportFormats, ", "))
+	if err := parseFlags(fs, args); err != nil {
+		return usageError(err, "lint", stderr)
+	}
+	if opts.FileExtension == "" {
+		return usageError(errors.New("--file-extension must not be empty"), "lint", stderr)
+	}
+	if opts.Report != "" && !slices.Contains(reportFormats, opts.Report) {
+		return usageError(fmt.Errorf("unknown report format %q (available: %s)", opts.Report, strings.Join(reportFormats, ", ")), "lint", stderr)
+	}
+	return runLint(opts, stdout)
+}
+
+// runConfig prints the configuration a build with the same options would use.
+func runConfig(args []string, stdout io.Writer, stderr io.Writer) int {
+	opts, err := parseOptions("config", args, stderr, nil)
+	if err != nil {
+		return usageError(err, "config", stderr)
+	}
+	source, _ := findConfig()
+	if err := writeConfig(stdout, opts, source); err != nil {
+		fmt.Fprintf(stderr, "fdl config: %v\n", err)
+		return exitFindings
+	}
+	return exitOK
+}
+
+// starterDocument is written by "fdl init".
+const starterDocument = `@title Getting Started
+@author Your Name
+@date 2024-01-01
+
+@abstract
+A short summary of this document.
+
+@section Introduction
+Write your documentation here.
+
+@info Run "fdl build" to convert this file.
+`
+
+func runInit(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := newFlagSet("init", "init [file]", stderr)
+	if err := parseArgs(fs, args); err != nil {
+		return usageError(err, "init", stderr)
+	}
+	if fs.NArg() > 1 {
+		return usageError(fmt.Errorf("unexpected argument %q", fs.Arg(1)), "init", stderr)
+	}
+	name := "getting-started.fdl"
+	if fs.NArg() == 1 {
+		name = fs.Arg(0)
+	}
+	if filepath.Ext(name) == "" {
+		name += ".fdl"
+	}
+
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		fmt.Fprintf(stderr, "fdl init: %v\n", err)
+		return exitFindings
+	}
+	if _, err := file.WriteString(starterDocument); err != nil {
+		file.Close()
+		fmt.Fprintf(stderr, "fdl init: %v\n", err)
+		return exitFindings
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintf(stderr, "fdl init: %v\n", err)
+		return exitFindings
+	}
+	fmt.Fprintf(stdout, "Created %s\n", name)
+	return exitOK
+}
+
+func runVersion(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := newFlagSet("version", "version", stderr)
+	if err := parseFlags(fs, args); err != nil {
+		return usageError(err, "version", stderr)
+	}
+	fmt.Fprintf(stdout, "FastDocumentationLanguage %s\n", version)
+	return exitOK
+}
+
+func runHelp(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stdout)
+		return exitOK
+	}
+	for _, c := range commands {
+		if c.name == args[0] && c.name != "help" {
+			return c.run([]string{"-h"}, stdout, stdout)
+		}
+	}
+	fmt.Fprintf(stderr, "fdl help: unknown command %q\n", args[0])
+	return exitUsage
+}
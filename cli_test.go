@@ -0,0 +1,98 @@
+package main
+
+import (
+	"FastDocumentationLanguage/fdl"
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunCommands(t *testing.T) {
+	tests := []struct {
+		args         []string
+		expectedCode int
+		stdout       string
+		stderr       string
+	}{
+		{[]string{"version"}, exitOK, "FastDocumentationLanguage " + version, ""},
+		{[]string{"--help"}, exitOK, "Usage: fdl <command> [options]", ""},
+		{[]string{"help"}, exitOK, "serve", ""},
+		{[]string{"help", "build"}, exitOK, "-file-extension", ""},
+		{[]string{"help", "nope"}, exitUsage, "", `unknown command "nope"`},
+		{[]string{"biuld"}, exitUsage, "", `fdl: unknown command "biuld"`},
+		{[]string{"build", "--nope"}, exitUsage, "", "flag provided but not defined: -nope"},
+		{[]string{"--format=docx"}, exitUsage, "", `unknown output format "docx"`},
+		{[]string{"lint", "-fe="}, exitUsage, "", "--file-extension must not be empty"},
+		{[]string{"lint", "--report=xml"}, exitUsage, "", `unknown report format "xml" (available: text, json)`},
+		{[]string{"version", "extra"}, exitUsage, "", `unexpected argument "extra"`},
+		{[]string{"render", "--nope", "-"}, exitUsage, "", "flag provided but not defined: -nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, &stdout, &stderr)
+			if code != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d (stderr: %s)", tt.expectedCode, code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.stdout) {
+				t.Errorf("Expected stdout to contain %q, got %q", tt.stdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("Expected stderr to contain %q, got %q", tt.stderr, stderr.String())
+			}
+			if tt.stderr != "" && strings.Count(stderr.String(), tt.stderr) > 1 {
+				t.Errorf("Expected %q only once, got %q", tt.stderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunInit(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"init", "manual"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	content, err := os.ReadFile("manual.fdl")
+	if err != nil {
+		t.Fatalf("Expected manual.fdl to be created: %v", err)
+	}
+	if string(content) != starterDocument {
+		t.Errorf("Unexpected starter document:\n%s", content)
+	}
+
+	// An existing document is never overwritten.
+	if code := run([]string{"init", "manual.fdl"}, &stdout, &stderr); code != exitFindings {
+		t.Errorf("Expected exit code 1 for an existing file, got %d", code)
+	}
+}
+
+func TestConditionFlags(t *testing.T) {
+	opts, err := parseBuildFlags([]string{"--var", "audience=internal", "--var=edition=pro", "--tag=beta,preview"}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseBuildFlags() failed: %v", err)
+	}
+	expected := fdl.Options{Path: "guide.fdl", Vars: map[string]string{"audience": "internal", "edition": "pro"}, Tags: []string{"beta", "preview"}}
+	if got := opts.documentOptions("guide.fdl", nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	if _, err := parseBuildFlags([]string{"--var=audience"}, io.Discard); err == nil {
+		t.Errorf("Expected an error for a variable without value")
+	}
+}
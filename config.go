@@ -0,0 +1,360 @@
+package main
+
+import (
+	"FastDocumentationLanguage/fdl"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configFiles are the names of the project configuration file in the working directory.
+// Only a small, flat subset of YAML and TOML is understood:
+//
+//	inputs: [docs, api]        inputs = ["docs", "api"]
+//	exclude:                   exclude = ["docs/drafts"]
+//	  - docs/drafts
+//	include: ["**/*.fdl"]      include = ["**/*.fdl"]
+//	output: site               output = "site"
+//	title: My Project          title = "My Project"
+var configFiles = []string{"fdl.yaml", "fdl.yml", "fdl.toml"}
+
+// configEntry is a single "key: value" setting of a configuration file.
+type configEntry struct {
+	fdl.Pos
+	Key    string
+	Values []string
+	// List is set if the value was written as a list.
+	List bool
+}
+
+// findConfig returns the name of the configuration file in the working directory,
+// or "" if there is none.
+func findConfig() (string, error) {
+	found := ""
+	for _, name := range configFiles {
+		if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("can't access %s: %w", name, err)
+		}
+		if found != "" {
+			return "", fmt.Errorf("both %s and %s exist, remove one of them", found, name)
+		}
+		found = name
+	}
+	return found, nil
+}
+
+// loadConfig applies the configuration file of the working directory to opts and
+// returns its name. Without a configuration file opts is left unchanged.
+func loadConfig(opts *options) (string, error) {
+	name, err := findConfig()
+	if err != nil || name == "" {
+		return "", err
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var entries []configEntry
+	if filepath.Ext(name) == ".toml" {
+		entries, err = parseTOMLConfig(file, name)
+	} else {
+		entries, err = parseYAMLConfig(file, name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return name, applyConfig(opts, entries)
+}
+
+func parseYAMLConfig(r io.Reader, name string) ([]configEntry, error) {
+	var entries []configEntry
+	// open is the index of the entry whose "- item" lines are read, -1 if there is none.
+	open := -1
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		pos := fdl.Pos{File: name, Line: line, Column: 1}
+		text := strings.TrimRight(stripConfigComment(scanner.Text()), " \t")
+		trimmed := strings.TrimLeft(text, " \t")
+		if trimmed == "" {
+			continue
+		}
+
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			if open < 0 {
+				return nil, fmt.Errorf("%s: list item without a setting", pos)
+			}
+			value, err := unquoteConfigValue(strings.TrimSpace(trimmed[1:]))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", pos, err)
+			}
+			entries[open].Values = append(entries[open].Values, value)
+			continue
+		}
+		if trimmed != text {
+			return nil, fmt.Errorf("%s: nested settings are not supported", pos)
+		}
+
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s: expected \"key: value\"", pos)
+		}
+		entry := configEntry{Pos: pos, Key: strings.TrimSpace(key)}
+		value = strings.TrimSpace(value)
+		open = -1
+		if value == "" {
+			// The values follow as "- item" lines.
+			entry.List = true
+			open = len(entries)
+		} else {
+			var err error
+			if entry.Values, entry.List, err = parseConfigValue(value); err != nil {
+				return nil, fmt.Errorf("%s: %v", pos, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func parseTOMLConfig(r io.Reader, name string) ([]configEntry, error) {
+	var entries []configEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		pos := fdl.Pos{File: name, Line: line, Column: 1}
+		text := strings.TrimSpace(stripConfigComment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			return nil, fmt.Errorf("%s: tables are not supported", pos)
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: expected \"key = value\"", pos)
+		}
+		value = strings.TrimSpace(value)
+		// Arrays may span several lines until the closing bracket.
+		for strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]") && scanner.Scan() {
+			line++
+			value += " " + strings.TrimSpace(stripConfigComment(scanner.Text()))
+		}
+		key, err := unquoteConfigValue(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", pos, err)
+		}
+		entry := configEntry{Pos: pos, Key: key}
+		if entry.Values, entry.List, err = parseConfigValue(value); err != nil {
+			return nil, fmt.Errorf("%s: %v", pos, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// stripConfigComment removes a "#" comment that is not part of a quoted value.
+func stripConfigComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseConfigValue parses a single value or a "[a, b]" list.
+func parseConfigValue(value string) ([]string, bool, error) {
+	if !strings.HasPrefix(value, "[") {
+		v, err := unquoteConfigValue(value)
+		return []string{v}, false, err
+	}
+	if !strings.HasSuffix(value, "]") {
+		return nil, true, errors.New("list is not closed with \"]\"")
+	}
+
+	var items []string
+	var quote rune
+	inner := value[1 : len(value)-1]
+	start := 0
+	for i, c := range inner {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, inner[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, true, errors.New("unterminated quoted value")
+	}
+	items = append(items, inner[start:])
+
+	values := []string{}
+	for _, item := range items {
+		// Empty items allow "[]" and a trailing comma.
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		v, err := unquoteConfigValue(item)
+		if err != nil {
+			return nil, true, err
+		}
+		values = append(values, v)
+	}
+	return values, true, nil
+}
+
+func unquoteConfigValue(value string) (string, error) {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	}
+	if strings.HasPrefix(value, "\"") {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value %s", value)
+		}
+		return unquoted, nil
+	}
+	return value, nil
+}
+
+// applyConfig sets the options of all entries.
+func applyConfig(opts *options, entries []configEntry) error {
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if seen[e.Key] {
+			return fmt.Errorf("%s: %q is set twice", e.Pos, e.Key)
+		}
+		seen[e.Key] = true
+
+		var err error
+		switch e.Key {
+		case "inputs":
+			opts.Inputs = e.Values
+		case "exclude":
+			opts.Exclude = e.Values
+		case "include":
+			opts.Include = e.Values
+		case "formats":
+			opts.Formats = e.Values
+		case "output":
+			opts.Directory, err = e.scalar()
+		case "extension":
+			opts.FileExtension, err = e.scalar()
+		case "theme":
+			opts.Theme, err = e.scalar()
+		case "title":
+			opts.SiteTitle, err = e.scalar()
+		case "combined":
+			opts.Combined, err = e.boolean()
+		case "devdoc":
+			opts.Devdoc, err = e.boolean()
+		case "numbered":
+			opts.Numbered, err = e.boolean()
+		case "vars":
+			opts.Vars = make(map[string]string)
+			for _, assignment := range e.Values {
+				key, value, varErr := fdl.ParseVar(assignment)
+				if varErr != nil {
+					err = varErr
+					break
+				}
+				opts.Vars[key] = value
+			}
+		case "tags":
+			opts.Tags = e.Values
+		default:
+			err = fmt.Errorf("unknown setting %q", e.Key)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", e.Pos, err)
+		}
+	}
+	return nil
+}
+
+func (e configEntry) scalar() (string, error) {
+	if e.List {
+		return "", fmt.Errorf("%q expects a single value, not a list", e.Key)
+	}
+	return e.Values[0], nil
+}
+
+func (e configEntry) boolean() (bool, error) {
+	value, err := e.scalar()
+	if err != nil {
+		return false, err
+	}
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q expects true or false, got %q", e.Key, value)
+}
+
+// writeConfig prints opts in the YAML form read by loadConfig. source is the
+// configuration file the options were read from, "" if there was none.
+func writeConfig(w io.Writer, opts options, source string) error {
+	var b strings.Builder
+	if source == "" {
+		fmt.Fprintf(&b, "# effective configuration, no %s found\n", strings.Join(configFiles, ", "))
+	} else {
+		fmt.Fprintf(&b, "# effective configuration, read from %s\n", source)
+	}
+	fmt.Fprintf(&b, "inputs: %s\n", formatConfigList(opts.Inputs))
+	fmt.Fprintf(&b, "exclude: %s\n", formatConfigList(opts.Exclude))
+	fmt.Fprintf(&b, "include: %s\n", formatConfigList(opts.Include))
+	fmt.Fprintf(&b, "output: %s\n", formatConfigValue(opts.Directory))
+	fmt.Fprintf(&b, "extension: %s\n", formatConfigValue(opts.FileExtension))
+	fmt.Fprintf(&b, "theme: %s\n", formatConfigValue(opts.Theme))
+	fmt.Fprintf(&b, "title: %s\n", formatConfigValue(opts.SiteTitle))
+	fmt.Fprintf(&b, "formats: %s\n", formatConfigList(opts.Formats))
+	fmt.Fprintf(&b, "combined: %t\n", opts.Combined)
+	fmt.Fprintf(&b, "devdoc: %t\n", opts.Devdoc)
+	fmt.Fprintf(&b, "numbered: %t\n", opts.Numbered)
+	fmt.Fprintf(&b, "vars: %s\n", formatConfigList(fdl.FormatVars(opts.Vars)))
+	fmt.Fprintf(&b, "tags: %s\n", formatConfigList(opts.Tags))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatConfigList(values []string) string {
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = formatConfigValue(v)
+	}
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
+
+// formatConfigValue quotes value if it would not be read back unchanged.
+func formatConfigValue(value string) string {
+	if value == "" || value == "true" || value == "false" || value != strings.TrimSpace(value) ||
+		strings.ContainsAny(value, ":#,[]\"'") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	yaml := `# project settings
+inputs: [docs, "api docs"]
+exclude:
+  - docs/drafts   # work in progress
+  - 'docs/old'
+output: site
+title: "Manual: #1"
+formats: html
+combined: true
+numbered: true
+vars: [audience=internal]
+tags:
+  - beta
+`
+	toml := `# project settings
+inputs = ["docs", "api docs"]
+exclude = [
+  "docs/drafts",  # work in progress
+  'docs/old',
+]
+output = "site"
+title = "Manual: #1"
+formats = "html"
+combined = true
+numbered = true
+vars = ["audience=internal"]
+tags = ["beta"]
+`
+	expected := defaultOptions()
+	expected.Inputs = []string{"docs", "api docs"}
+	expected.Exclude = []string{"docs/drafts", "docs/old"}
+	expected.Directory = "site"
+	expected.SiteTitle = "Manual: #1"
+	expected.Formats = []string{"html"}
+	expected.Combined = true
+	expected.Numbered = true
+	expected.Vars = map[string]string{"audience": "internal"}
+	expected.Tags = []string{"beta"}
+
+	parsers := map[string]func(io.Reader, string) ([]configEntry, error){
+		"fdl.yaml": parseYAMLConfig,
+		"fdl.toml": parseTOMLConfig,
+	}
+	inputs := map[string]string{"fdl.yaml": yaml, "fdl.toml": toml}
+	for name, parse := range parsers {
+		t.Run(name, func(t *testing.T) {
+			entries, err := parse(strings.NewReader(inputs[name]), name)
+			if err != nil {
+				t.Fatalf("Parsing failed: %v", err)
+			}
+			got := defaultOptions()
+			if err := applyConfig(&got, entries); err != nil {
+				t.Fatalf("applyConfig() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected %+v, got %+v", expected, got)
+			}
+		})
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"fdl.yaml", "theme: dark\ncolor: red\n", `fdl.yaml:2:1: unknown setting "color"`},
+		{"fdl.yaml", "title: A\ntitle: B\n", `fdl.yaml:2:1: "title" is set twice`},
+		{"fdl.yaml", "title: [A, B]\n", `fdl.yaml:1:1: "title" expects a single value, not a list`},
+		{"fdl.yaml", "combined: yes\n", `fdl.yaml:1:1: "combined" expects true or false, got "yes"`},
+		{"fdl.yaml", "vars: [audience]\n", `fdl.yaml:1:1: invalid variable "audience", expected key=value`},
+		{"fdl.yaml", "- docs\n", "fdl.yaml:1:1: list item without a setting"},
+		{"fdl.yaml", "site:\n  title: A\n", "fdl.yaml:2:1: nested settings are not supported"},
+		{"fdl.yaml", "inputs: [docs\n", `fdl.yaml:1:1: list is not closed with "]"`},
+		{"fdl.toml", "[site]\n", "fdl.toml:1:1: tables are not supported"},
+		{"fdl.toml", "title \"A\"\n", `fdl.toml:1:1: expected "key = value"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			parse := parseYAMLConfig
+			if strings.HasSuffix(tt.name, ".toml") {
+				parse = parseTOMLConfig
+			}
+			entries, err := parse(strings.NewReader(tt.input), tt.name)
+			if err == nil {
+				opts := defaultOptions()
+				err = applyConfig(&opts, entries)
+			}
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected error %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestConfigOverriddenByFlags(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+
+	config := "output: site\ntheme: dark\nformats: [html, pdf]\n"
+	if err := os.WriteFile("fdl.yaml", []byte(config), 0644); err != nil {
+		t.Fatalf("Could not create config file: %v", err)
+	}
+
+	opts, err := parseBuildFlags([]string{"--format=markdown"}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseBuildFlags() failed: %v", err)
+	}
+	if opts.Directory != "site" || opts.Theme != "dark" {
+		t.Errorf("Expected the settings of fdl.yaml, got %+v", opts)
+	}
+	if !reflect.DeepEqual(opts.Formats, []string{"markdown"}) {
+		t.Errorf("Expected --format to override fdl.yaml, got %v", opts.Formats)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"config", "-dir=/out"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	expected := "# effective configuration, read from fdl.yaml\n" +
+		"inputs: [.]\nexclude: []\ninclude: []\noutput: /out\nextension: .fdl\ntheme: dark\n" +
+		"title: Documentation\nformats: [html, pdf]\ncombined: false\ndevdoc: false\nnumbered: false\nvars: []\ntags: []\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, stdout.String())
+	}
+
+	// The printed configuration is a valid configuration file itself.
+	entries, err := parseYAMLConfig(&stdout, "printed")
+	if err != nil {
+		t.Fatalf("Printed configuration can't be read back: %v", err)
+	}
+	readBack := defaultOptions()
+	if err := applyConfig(&readBack, entries); err != nil || readBack.Directory != "/out" {
+		t.Errorf("Expected the printed configuration to read back unchanged, got %+v (%v)", readBack, err)
+	}
+
+	if err := os.WriteFile("fdl.toml", []byte(config), 0644); err != nil {
+		t.Fatalf("Could not create config file: %v", err)
+	}
+	if _, err := parseBuildFlags(nil, io.Discard); err == nil {
+		t.Errorf("Expected an error if fdl.yaml and fdl.toml both exist")
+	}
+}
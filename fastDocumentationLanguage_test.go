@@ -1,10 +1,13 @@
 package main
 
 import (
-	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -36,7 +39,10 @@ func TestGetFdlFilePath(t *testing.T) {
 	}(originalDir)
 
 	// Test function
-	files := getFilePath(".fdl")
+	files, err := getFilePath(".fdl")
+	if err != nil {
+		t.Fatalf("getFilePath failed: %v", err)
+	}
 	if len(files) != 1 {
 		t.Errorf("Expected 1 .fdl file, got %d", len(files))
 	}
@@ -47,110 +53,72 @@ func TestGetFdlFilePath(t *testing.T) {
 	}
 }
 
-func TestConvertFileNameToHTMLFile(t *testing.T) {
-	input := "example.fdl"
-	expected := "example.html"
-	result := convertFileNameToHTMLFile(input)
-	if result != expected {
-		t.Errorf("Expected %s, got %s", expected, result)
+func TestCreateOutputDir(t *testing.T) {
+	// Setup test directory
+	testDir := "fdlDocumentation"
+	if err := os.Mkdir(testDir, 0755); err != nil && !os.IsExist(err) {
+		t.Fatalf("Failed to create test directory: %v", err)
 	}
-}
+	defer os.RemoveAll(testDir)
 
-func TestFormatInfo(t *testing.T) {
-	input := "@info This is an info message."
-	expected := "<div style='background-color:#e7f3fe;padding:10px;border-left:6px solid #2196F3;'><strong>Info:" +
-		"</strong> This is an info message.</div>"
-	result := formatInfo(input)
-	if result != expected {
-		t.Errorf("Expected %s, got %s", expected, result)
+	if _, err := createOutputDir("fdlDocumentation", false); err != nil {
+		t.Fatalf("createOutputDir failed: %v", err)
 	}
-}
 
-func TestFormatWarning(t *testing.T) {
-	input := "@warning This is a warning message."
-	expected := "<div style='background-color:#ffcccb;padding:10px;border-left:6px solid #f44336;'><strong>Warning:" +
-		"</strong> This is a warning message.</div>"
-	result := formatWarning(input)
-	if result != expected {
-		t.Errorf("Expected %s, got %s", expected, result)
-	} else {
-		fmt.Println(result != expected)
+	// Check if directory exists
+	if _, err := os.Stat(testDir); os.IsNotExist(err) {
+		t.Errorf("Expected directory %s to exist, but it does not", testDir)
 	}
 }
 
-func TestProcessSection(t *testing.T) {
-	sections := make(map[string]string)
-	input := "@section Introduction"
-	expected := "<h2 id='introduction'>Introduction</h2>"
-	result := processSection(input, sections)
-	if result != expected {
-		t.Errorf("Expected %s, got %s", expected, result)
+func TestCreateOutputDirSafety(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"docs/guide.fdl": "@title Guide"})
+
+	// Ein Verzeichnis mit fremden Dateien wird weder benutzt noch gelöscht.
+	for _, clean := range []bool{false, true} {
+		if _, err := createOutputDir("docs", clean); err == nil || !strings.Contains(err.Error(), buildMarker) {
+			t.Errorf("Expected an error for a directory without %s, got %v", buildMarker, err)
+		}
 	}
-	if sections["introduction"] != "Introduction" {
-		t.Errorf("Expected section 'Introduction', got %s", sections["introduction"])
+	if _, err := os.Stat("docs/guide.fdl"); err != nil {
+		t.Errorf("Expected docs/guide.fdl to be kept: %v", err)
 	}
-}
-
-func TestProcessDefaultLine(t *testing.T) {
-	input := "This is a regular line."
-	expected := "This is a regular line.<br>"
-	result := processDefaultLine(input, false, false)
-	if result != expected {
-		t.Errorf("Expected %s, got %s", expected, result)
+	for _, directory := range []string{".", ".."} {
+		if _, err := createOutputDir(directory, true); err == nil {
+			t.Errorf("Expected an error for the output directory %q", directory)
+		}
 	}
 
-	// Test within code block
-	input = "    code block line"
-	expected = "    code block line\n"
-	result = processDefaultLine(input, true, false)
-	if result != expected {
-		t.Errorf("Expected %s, got %s", expected, result)
+	// Absolute Pfade werden nicht an das Arbeitsverzeichnis angehängt.
+	absolute := filepath.Join(t.TempDir(), "out")
+	outputPath, err := createOutputDir(absolute, false)
+	if err != nil || outputPath != absolute {
+		t.Fatalf("Expected %s, got %s, %v", absolute, outputPath, err)
 	}
-
-	// Test within table (should return empty)
-	input = "| Table | Line |"
-	expected = ""
-	result = processDefaultLine(input, false, true)
-	if result != expected {
-		t.Errorf("Expected empty string, got %s", result)
+	if !hasBuildMarker(absolute) {
+		t.Errorf("Expected %s to contain %s", absolute, buildMarker)
 	}
-}
-
-func TestGenerateTableOfContents(t *testing.T) {
-	sections := map[string]string{
-		"section-1": "Introduction",
-		"section-2": "Details",
+	writeFiles(t, map[string]string{filepath.Join(absolute, "old.html"): "old"})
+	if _, err := createOutputDir(absolute, false); err != nil {
+		t.Fatalf("Expected the marked directory to be reused, got %v", err)
 	}
-	expected := "<h2>Table of Contents</h2><ul><li><a href='#section-1'>Introduction</a></li><li>" +
-		"<a href='#section-2'>Details</a></li></ul>"
-	result := generateTableOfContents(sections)
-	if result != expected {
-		t.Errorf("Expected %s, got %s", expected, result)
+	if _, err := os.Stat(filepath.Join(absolute, "old.html")); err != nil {
+		t.Errorf("Expected old.html to be kept without --clean: %v", err)
 	}
-}
-
-func TestEscapeHTML(t *testing.T) {
-	input := "<div>Example</div>"
-	expected := "&lt;div&gt;Example&lt;/div&gt;"
-	result := escapeHTML(input)
-	if result != expected {
-		t.Errorf("Expected %s, got %s", expected, result)
+	if _, err := createOutputDir(absolute, true); err != nil {
+		t.Fatalf("createOutputDir failed: %v", err)
 	}
-}
-
-func TestCreateOrCleanOutputDir(t *testing.T) {
-	// Setup test directory
-	testDir := "fdlDocumentation"
-	if err := os.Mkdir(testDir, 0755); err != nil && !os.IsExist(err) {
-		t.Fatalf("Failed to create test directory: %v", err)
+	if _, err := os.Stat(filepath.Join(absolute, "old.html")); !os.IsNotExist(err) {
+		t.Errorf("Expected old.html to be deleted with --clean, got %v", err)
+	}
+	if !hasBuildMarker(absolute) {
+		t.Errorf("Expected %s to contain %s after cleaning", absolute, buildMarker)
 	}
-	defer os.RemoveAll(testDir)
-
-	createOrCleanOutputDir("/fdlDocumentation")
 
-	// Check if directory exists
-	if _, err := os.Stat(testDir); os.IsNotExist(err) {
-		t.Errorf("Expected directory %s to exist, but it does not", testDir)
+	opts, err := parseBuildFlags([]string{"--clean", "-dir", absolute}, io.Discard)
+	if err != nil || !opts.Clean || opts.Directory != absolute {
+		t.Errorf("Expected --clean to be set, got %+v, %v", opts, err)
 	}
 }
 
@@ -186,7 +154,9 @@ func TestProcessFileDefaultMode(t *testing.T) {
 	}
 
 	// Run the processFileDefaultMode function.
-	processFiles()
+	if diags := processFiles(defaultOptions()); diags.HasErrors() {
+		t.Errorf("Expected no errors, got %v", diags.Sorted())
+	}
 
 	// Verify the output.
 	outputFile := filepath.Join(tempDir, "documentation", "test.html")
@@ -212,173 +182,116 @@ func TestProcessFileDefaultMode(t *testing.T) {
 	}
 }
 
-func TestParseLine(t *testing.T) {
-	tests := []struct {
-		line                       string
-		inCodeBlock                bool
-		inTable                    bool
-		inList                     bool
-		isUseCaseORExample         bool
-		expectedOutput             string
-		expectedInCodeBlock        bool
-		expectedInTable            bool
-		expectedInList             bool
-		expectedIsUseCaseORExample bool
-	}{
-		// Testcases for different types of lines and states
-
-		// @title
-		{"@title My Title", false, false, false, false, "<h1>My Title</h1>", false, false, false, false},
-
-		// @author
-		{"@author John Doe", false, false, false, false, "<p>Author: John Doe</p>", false, false, false, false},
-
-		// @date
-		{"@date 2024-08-25", false, false, false, false, "<p>Date: 2024-08-25</p>", false, false, false, false},
-
-		// @abstract
-		{"@abstract", false, false, false, false, "<h2>Abstract</h2><p>", false, false, false, false},
-
-		// @info
-		{"@info Information", false, false, false, false, formatInfo("@info Information"), false, false, false, false},
-
-		// @warning
-		{"@warning Warning Message", false, false, false, false, formatWarning("@warning Warning Message"), false, false, false, false},
-
-		// @note
-		{"@note This is a note", false, false, false, false, "<p><em>Note:</em> This is a note</p>", false, false, false, false},
-
-		// @code and @endcode
-		{"@code", false, false, false, false, "<div class='example-box'><div class='example-title'>Code:</div><div class='example-content'><pre><code>", true, false, false, false},
-		{"@endcode", true, false, false, false, "</code></pre></div></div>", false, false, false, false},
-		{"@code", false, false, false, true, "<pre><code>", true, false, false, true},
-		{"@endcode", true, false, false, true, "</code></pre>", false, false, false, true},
-
-		// @tbc
-		{"@tbc", false, false, false, false, "", false, false, false, false},
-
-		// @table and @row
-		{"@table", false, false, false, false, "<table border='1'>", false, true, false, false},
-		{"@row cell1|cell2|cell3", false, true, false, false, "<tr><td>cell1</td><td>cell2</td><td>cell3</td></tr>", false, true, false, false},
-		{"@endtable", false, true, false, false, "</table>", false, false, false, false},
-
-		// @version
-		{"@version 1.0.0", false, false, false, false, "<p><em>Version:</em> 1.0.0</p>", false, false, false, false},
-
-		// @since
-		{"@since 2024", false, false, false, false, "<p><em>Since:</em> 2024</p>", false, false, false, false},
-
-		// @deprecated
-		{"@deprecated", false, false, false, false, "<strong><em style='color:red;'>Deprecated!</em></strong>", false, false, false, false},
-
-		// @param
-		{"@param param1|param2", false, false, false, false, "<p><b>Parameters</b></p><p>param1</p><p>param2</p>", false, false, false, false},
-
-		// @return
-		{"@return return1|return2", false, false, false, false, "<p><b>Return:</b></p><p>return1</p><p>return2</p>", false, false, false, false},
-
-		// @list
-		{"@list -n", false, false, false, false, "<ol>", false, false, true, false},
-		{"@list", false, false, false, false, "<ul>", false, false, true, false},
-
-		// @item
-		{"@item List item", false, false, true, false, "<li>List item</li>", false, false, true, false},
-
-		// @endlist
-		{"@endlist", false, false, true, false, "</ul></ol>", false, false, false, false},
-
-		// @tip
-		{"@tip This is a tip", false, false, false, false, formatTip("This is a tip"), false, false, false, false},
-
-		// @todo
-		{"@todo This is a todo", false, false, false, false, "<p><em>TODO:</em> This is a todo</p>", false, false, false, false},
-
-		// @example
-		{"@example", false, false, false, false, "<div class='example-box'><div class='example-title'>Example:</div><div class='example-content'>", false, false, false, true},
-		{"@endexample", false, false, false, true, "</div></div>", false, false, false, false},
-
-		// @usecase
-		{"@usecase", false, false, false, false, "<div class='example-box'><div class='example-title'>UseCase:</div><div class='example-content'>", false, false, false, true},
-		{"@endusecase", false, false, false, true, "</div></div>", false, false, false, false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.line, func(t *testing.T) {
-			got, gotInCodeBlock, gotInTable, gotInList, gotIsUseCaseORExample := parseLine(tt.line, tt.inCodeBlock, tt.inTable, tt.inList, tt.isUseCaseORExample, nil)
-			if got != tt.expectedOutput {
-				t.Errorf("parseLine() = %v, want %v", got, tt.expectedOutput)
-			}
-			if gotInCodeBlock != tt.expectedInCodeBlock {
-				t.Errorf("parseLine() inCodeBlock = %v, want %v", gotInCodeBlock, tt.expectedInCodeBlock)
-			}
-			if gotInTable != tt.expectedInTable {
-				t.Errorf("parseLine() inTable = %v, want %v", gotInTable, tt.expectedInTable)
-			}
-			if gotInList != tt.expectedInList {
-				t.Errorf("parseLine() inList = %v, want %v", gotInList, tt.expectedInList)
-			}
-			if gotIsUseCaseORExample != tt.expectedIsUseCaseORExample {
-				t.Errorf("parseLine() isUseCaseORExample = %v, want %v", gotIsUseCaseORExample, tt.expectedIsUseCaseORExample)
-			}
-		})
-	}
-}
-
-// TestGetFlagsFromCli testet die getFlagsFromCli Funktion
-func TestGetFlagsFromCli(t *testing.T) {
+// TestParseBuildFlags testet die parseBuildFlags Funktion
+func TestParseBuildFlags(t *testing.T) {
 	tests := []struct {
 		args        []string
-		expected    flag
+		expected    func(o *options)
 		description string
 	}{
 		{
-			args:        []string{"cmd", "--file-extension=.txt", "--directory=/docs"},
-			expected:    flag{FileExtension: ".txt", Directory: "/docs"},
+			args:        []string{"--file-extension=.txt", "--directory=/docs"},
+			expected:    func(o *options) { o.FileExtension = ".txt"; o.Directory = "/docs" },
 			description: "Valid flags provided",
 		},
 		{
-			args:        []string{"cmd"},
-			expected:    flag{FileExtension: ".fdl", Directory: "/documentation"},
+			args:        []string{},
+			expected:    func(o *options) {},
 			description: "Default flags used",
 		},
 		{
-			args:        []string{"cmd", "-fe=.md"},
-			expected:    flag{FileExtension: ".md", Directory: "/documentation"},
+			args:        []string{"-fe=.md"},
+			expected:    func(o *options) { o.FileExtension = ".md" },
 			description: "Short flag for file extension",
 		},
 		{
-			args:        []string{"cmd", "-dir=./custom"},
-			expected:    flag{FileExtension: ".fdl", Directory: "./custom"},
+			args:        []string{"-dir=./custom"},
+			expected:    func(o *options) { o.Directory = "./custom" },
 			description: "Short flag for directory",
 		},
 		{
-			args:        []string{"cmd", "--file-extension=invalid"},
-			expected:    flag{FileExtension: "invalid", Directory: "/documentation"},
+			args:        []string{"--file-extension=invalid"},
+			expected:    func(o *options) { o.FileExtension = "invalid" },
 			description: "Invalid file extension flag",
 		},
+		{
+			args:        []string{"--format=html"},
+			expected:    func(o *options) {},
+			description: "Output format flag",
+		},
+		{
+			args:        []string{"-fmt=pdf", "-combined"},
+			expected:    func(o *options) { o.Formats = []string{"pdf"}; o.Combined = true },
+			description: "Combined PDF output",
+		},
+		{
+			args:        []string{"--format=html, markdown"},
+			expected:    func(o *options) { o.Formats = []string{"html", "markdown"} },
+			description: "Several output formats",
+		},
+		{
+			args:        []string{"--directory", "/docs", "-dev-doc"},
+			expected:    func(o *options) { o.Directory = "/docs"; o.Devdoc = true },
+			description: "Flag value as separate argument",
+		},
+		{
+			args: []string{"-in=docs,api", "--exclude=docs/drafts", "--theme=dark", "--title=Manual"},
+			expected: func(o *options) {
+				o.Inputs = []string{"docs", "api"}
+				o.Exclude = []string{"docs/drafts"}
+				o.Theme = "dark"
+				o.SiteTitle = "Manual"
+			},
+			description: "Project settings",
+		},
+		{
+			args:        []string{"-j=4", "--rebuild"},
+			expected:    func(o *options) { o.Jobs = 4; o.Rebuild = true },
+			description: "Parallel full rebuild",
+		},
 	}
 
-	// Speichern des ursprünglichen os.Args
-	originalArgs := os.Args
-
 	// Testen der Fälle
 	for _, tt := range tests {
 		t.Run(tt.description, func(t *testing.T) {
-			// Setze os.Args auf die Testdaten
-			os.Args = tt.args
-
-			// Rufe die Funktion auf
-			got := getFlagsFromCli()
+			got, err := parseBuildFlags(tt.args, io.Discard)
+			if err != nil {
+				t.Fatalf("parseBuildFlags() failed: %v", err)
+			}
 
 			// Überprüfe, ob das Ergebnis den Erwartungen entspricht
-			if got != tt.expected {
-				t.Errorf("getFlagsFromCli() = %+v; want %+v", got, tt.expected)
+			expected := defaultOptions()
+			tt.expected(&expected)
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("parseBuildFlags() = %+v; want %+v", got, expected)
 			}
 		})
 	}
+}
 
-	// Stelle os.Args auf die ursprünglichen Werte zurück
-	os.Args = originalArgs
+func TestParseBuildFlagsErrors(t *testing.T) {
+	tests := []struct {
+		args        []string
+		description string
+	}{
+		{[]string{"-directory-foo=/docs"}, "Unknown flag with known prefix"},
+		{[]string{"--file-extension"}, "Flag without value"},
+		{[]string{"--file-extension="}, "Empty file extension"},
+		{[]string{"--format=docx"}, "Unknown output format"},
+		{[]string{"--format=html,docx"}, "Unknown second output format"},
+		{[]string{"--format="}, "No output format"},
+		{[]string{"--theme=neon"}, "Unknown theme"},
+		{[]string{"--jobs=0"}, "No workers"},
+		{[]string{"input.fdl"}, "Unexpected positional argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if _, err := parseBuildFlags(tt.args, io.Discard); err == nil {
+				t.Errorf("Expected an error for %v", tt.args)
+			}
+		})
+	}
 }
 
 func TestGetFilePath(t *testing.T) {
@@ -418,7 +331,10 @@ func TestGetFilePath(t *testing.T) {
 	}
 
 	// Teste die getFilePath Funktion mit der .fdl Erweiterung
-	got := getFilePath(".fdl")
+	got, err := getFilePath(".fdl")
+	if err != nil {
+		t.Fatalf("getFilePath failed: %v", err)
+	}
 
 	// Überprüfe, ob die richtigen Dateipfade zurückgegeben werden
 	expectedPaths := []string{
@@ -443,6 +359,34 @@ func TestGetFilePath(t *testing.T) {
 	}
 }
 
+// writeFiles legt die Dateien relativ zum aktuellen Verzeichnis an.
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("Could not create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("Could not create %s: %v", name, err)
+		}
+	}
+}
+
+// inTempDir changes into a new temporary directory until the test ends.
+func inTempDir(t *testing.T) {
+	t.Helper()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+}
+
 // Hilfsfunktion, um zu überprüfen, ob ein Slice einen bestimmten String enthält
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -452,3 +396,231 @@ func contains(slice []string, item string) bool {
 	}
 	return false
 }
+
+func TestFindDocuments(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+
+	for _, name := range []string{"root.fdl", "docs/a.fdl", "docs/drafts/b.fdl", "docs/draftsman.fdl", "api/c.fdl"} {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("Could not create directory: %v", err)
+		}
+		if err := os.WriteFile(name, nil, 0644); err != nil {
+			t.Fatalf("Could not create test file: %v", err)
+		}
+	}
+
+	// Überlappende Eingabeverzeichnisse liefern jede Datei nur einmal.
+	files, err := findDocuments(options{FileExtension: ".fdl", Inputs: []string{"docs", "api", "docs"}, Exclude: []string{"docs/drafts/"}})
+	if err != nil {
+		t.Fatalf("findDocuments failed: %v", err)
+	}
+	var got []string
+	for _, file := range files {
+		rel, _ := filepath.Rel(tempDir, file)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	expected := []string{"docs/a.fdl", "docs/draftsman.fdl", "api/c.fdl"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if _, err := findDocuments(options{FileExtension: ".fdl", Inputs: []string{"missing"}}); err == nil {
+		t.Errorf("Expected an error for a missing input directory")
+	}
+}
+
+func TestLessDocumentPath(t *testing.T) {
+	paths := []string{"docs/guide/intro.fdl", "docs/z.fdl", "docs/api-v1/a.fdl", "docs/api/v2/a.fdl", "docs/api/intro.fdl", "docs/a.fdl"}
+	for i := range paths {
+		paths[i] = filepath.FromSlash(paths[i])
+	}
+	sort.SliceStable(paths, func(i, j int) bool { return lessDocumentPath(paths[i], paths[j]) })
+	expected := "docs/a.fdl docs/z.fdl docs/api/intro.fdl docs/api/v2/a.fdl docs/api-v1/a.fdl docs/guide/intro.fdl"
+	if result := filepath.ToSlash(strings.Join(paths, " ")); result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestOutputMirrorsSources(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"docs/api/intro.fdl":   "@title API\n@ref ../guide/intro.fdl Guide",
+		"docs/guide/intro.fdl": "@title Guide",
+		"docs/old/legacy.fdl":  "@title Legacy",
+	})
+	opts := defaultOptions()
+	opts.Formats = []string{"html", "markdown"}
+	if diags := processFiles(opts); diags.HasErrors() {
+		t.Fatalf("Expected no errors, got %v", diags.Sorted())
+	}
+
+	api, err := os.ReadFile(filepath.Join("documentation", "docs", "api", "intro.html"))
+	if err != nil || !strings.Contains(string(api), "<a href='../guide/intro.html'>Guide</a>") {
+		t.Errorf("Expected docs/api/intro.html with a link to the guide, got %s (%v)", api, err)
+	}
+	guide, err := os.ReadFile(filepath.Join("documentation", "docs", "guide", "intro.md"))
+	if err != nil || !strings.Contains(string(guide), "# Guide") {
+		t.Errorf("Expected docs/guide/intro.md, got %s (%v)", guide, err)
+	}
+	index, err := os.ReadFile(filepath.Join("documentation", "index.html"))
+	if err != nil || !strings.Contains(string(index), "<li>api<ul><li> <a href='docs/api/intro.html'>1 intro</a></li></ul></li><li>guide<ul>") {
+		t.Errorf("Expected the index to be grouped by folder, got %s (%v)", index, err)
+	}
+
+	// Mit der letzten Quelle eines Ordners verschwindet auch der Ordner in der Ausgabe.
+	if err := os.RemoveAll(filepath.Join("docs", "old")); err != nil {
+		t.Fatalf("Could not remove docs/old: %v", err)
+	}
+	if diags := processFiles(opts); diags.HasErrors() {
+		t.Fatalf("Expected no errors, got %v", diags.Sorted())
+	}
+	if _, err := os.Stat(filepath.Join("documentation", "docs", "old")); !os.IsNotExist(err) {
+		t.Errorf("Expected the empty folder docs/old to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("documentation", "docs", "api", "intro.html")); err != nil {
+		t.Errorf("Expected docs/api/intro.html to be kept, got %v", err)
+	}
+}
+
+func TestOutputFilesOutsideTheWorkingDirectory(t *testing.T) {
+	external := t.TempDir()
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		filepath.Join(external, "a", "intro.fdl"): "@title A",
+		filepath.Join(external, "b", "intro.fdl"): "@title B",
+		"intro.draft.fdl":                         "@title Draft",
+	})
+	opts := defaultOptions()
+	opts.Inputs = []string{".", external}
+	if diags := processFiles(opts); diags.HasErrors() {
+		t.Fatalf("Expected no errors, got %v", diags.Sorted())
+	}
+	// Dokumente außerhalb des Arbeitsverzeichnisses werden relativ zu ihrem Eingabeordner abgelegt.
+	for _, name := range []string{filepath.Join("a", "intro.html"), filepath.Join("b", "intro.html"), "intro.draft.html"} {
+		if _, err := os.Stat(filepath.Join("documentation", name)); err != nil {
+			t.Errorf("Expected %s to be written, got %v", name, err)
+		}
+	}
+}
+
+func TestOutputFileConflicts(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"index.fdl":         "@title Index",
+		"documentation.fdl": "@title Documentation",
+		"guide.fdl":         "@title Guide",
+		"guide.txt.fdl":     "@title Guide text",
+	})
+	opts := defaultOptions()
+	opts.Combined = true
+	var messages []string
+	for _, d := range processFiles(opts).Sorted() {
+		messages = append(messages, d.String())
+	}
+	expected := []string{
+		"documentation.fdl: error[FDL005]: the output file documentation.html is already written for the combined documentation",
+		"index.fdl: error[FDL005]: the output file index.html is already written for the index",
+	}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("Expected %v, got %v", expected, messages)
+	}
+
+	// Zwei Eingabeordner außerhalb des Arbeitsverzeichnisses mit derselben Datei.
+	first, second := t.TempDir(), t.TempDir()
+	writeFiles(t, map[string]string{filepath.Join(first, "intro.fdl"): "@title A", filepath.Join(second, "intro.fdl"): "@title B"})
+	opts.Combined = false
+	opts.Inputs = []string{first, second}
+	diags := processFiles(opts).Sorted()
+	if len(diags) != 1 || !strings.HasSuffix(diags[0].String(), "error[FDL005]: the output file intro.html is already written for "+filepath.ToSlash(displayPath(filepath.Join(first, "intro.fdl")))) {
+		t.Errorf("Expected the second intro.fdl to be reported, got %v", diags)
+	}
+}
+
+const draftInput = `@title Guide
+@todo Describe the setup
+@section Setup
+Run the installer.
+@tbc
+@internal
+Ask ops for the staging password.
+@endinternal
+@example
+@todo Better example
+@endexample
+`
+
+func TestDevdocBuild(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+	if err := os.WriteFile("guide.fdl", []byte(draftInput), 0644); err != nil {
+		t.Fatalf("Could not create test file: %v", err)
+	}
+
+	tests := []struct {
+		devdoc   bool
+		expected bool
+	}{
+		{false, false},
+		{true, true},
+	}
+	for _, tt := range tests {
+		opts := defaultOptions()
+		opts.Formats = []string{"html", "markdown"}
+		opts.Devdoc = tt.devdoc
+		if diags := processFiles(opts); diags.HasErrors() {
+			t.Fatalf("Expected no errors, got %v", diags.Sorted())
+		}
+
+		for _, file := range []string{"guide.html", "guide.md", "index.html", "index.md"} {
+			content, err := os.ReadFile(filepath.Join("documentation", file))
+			if err != nil {
+				t.Fatalf("Failed to read output file: %v", err)
+			}
+			if got := strings.Contains(string(content), "DRAFT"); got != tt.expected {
+				t.Errorf("devdoc=%t: expected draft banner in %s to be %t", tt.devdoc, file, tt.expected)
+			}
+			if strings.HasPrefix(file, "guide") {
+				for _, draft := range []string{"TODO:", "To be continued", "Internal:", "staging password"} {
+					if got := strings.Contains(string(content), draft); got != tt.expected {
+						t.Errorf("devdoc=%t: expected %q in %s to be %t", tt.devdoc, draft, file, tt.expected)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestUnusedFragmentsAreReported(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"guide.fdl":          "@title Guide\n@include parts/_contact.fdl",
+		"parts/_contact.fdl": "@include _address.fdl",
+		"parts/_address.fdl": "Address",
+		"_changelog.fdl":     "@title Changelog",
+	})
+	diags := processFiles(defaultOptions())
+	// Nur das Fragment, das kein Dokument einbindet, wird gemeldet.
+	expected := `_changelog.fdl: warning[FDL004]: _changelog.fdl is not converted because its name starts with "_", but no document includes it`
+	if sorted := diags.Sorted(); len(sorted) != 1 || sorted[0].String() != expected {
+		t.Errorf("Expected %s, got %v", expected, sorted)
+	}
+}
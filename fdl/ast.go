@@ -0,0 +1,279 @@
+package fdl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Pos is the location of a node in its source file. Line and Column are 1-based.
+type Pos struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Pos) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Position returns the position itself, so every node embedding Pos satisfies Node.
+func (p Pos) Position() Pos {
+	return p
+}
+
+// Node is any element of a parsed .fdl document.
+type Node interface {
+	Position() Pos
+}
+
+// Container is a node that holds other nodes as children.
+type Container interface {
+	Node
+	appendChild(n Node)
+}
+
+// Document is the root of a parsed .fdl file.
+type Document struct {
+	Pos
+	Path string
+	// Name is the path the output of the document mirrors, see OutputFile. It is set by
+	// the caller, e.g. "fdl build" names documents outside of the working directory
+	// relative to their input directory. Without a name, Path is used.
+	Name string
+	// Hash identifies the source the document was parsed from, see HashSource.
+	Hash string
+	// Dependencies are the paths of the other documents whose content ends up in the
+	// output of this one, e.g. through @ref. They are sorted and use "/" as separator.
+	Dependencies []string
+	// Includes maps the files spliced in with @include to the hash of their source.
+	Includes map[string]string
+	Children []Node
+}
+
+// addDependency records that the output of d depends on the document at path.
+func (d *Document) addDependency(path string) {
+	i := sort.SearchStrings(d.Dependencies, path)
+	if i < len(d.Dependencies) && d.Dependencies[i] == path {
+		return
+	}
+	d.Dependencies = append(d.Dependencies, "")
+	copy(d.Dependencies[i+1:], d.Dependencies[i:])
+	d.Dependencies[i] = path
+}
+
+func (d *Document) appendChild(n Node) {
+	d.Children = append(d.Children, n)
+}
+
+// MetaKind identifies which metadata directive produced a Metadata node.
+type MetaKind int
+
+const (
+	MetaTitle MetaKind = iota
+	MetaAuthor
+	MetaDate
+	MetaVersion
+	MetaSince
+)
+
+// Metadata is a single @title, @author, @date, @version or @since line.
+type Metadata struct {
+	Pos
+	Kind  MetaKind
+	Value string
+}
+
+// Abstract marks the start of the abstract (@abstract).
+type Abstract struct {
+	Pos
+}
+
+// Section is a @section, @subsection or @subsubsection heading together with everything
+// up to the next heading of the same or a higher level. Deeper sections are children.
+type Section struct {
+	Pos
+	// Level is 1 for @section, 2 for @subsection and 3 for @subsubsection.
+	Level int
+	Title string
+	ID    string
+	// Number is the hierarchical number like "2.1.3", set by numberSections.
+	Number   string
+	Children []Node
+}
+
+// Heading returns the title, preceded by the number if the sections are numbered.
+func (s *Section) Heading() string {
+	if s.Number == "" {
+		return s.Title
+	}
+	return s.Number + " " + s.Title
+}
+
+func (s *Section) appendChild(n Node) {
+	s.Children = append(s.Children, n)
+}
+
+// CodeBlock is the verbatim content between @code and @endcode.
+type CodeBlock struct {
+	Pos
+	Lines []string
+}
+
+// Table is a @table ... @endtable block.
+type Table struct {
+	Pos
+	Rows []*Row
+}
+
+// Row is a single @row of a table, split at "|".
+type Row struct {
+	Pos
+	Cells []string
+}
+
+// List is a @list ... @endlist block. "@list -n" produces an ordered list.
+type List struct {
+	Pos
+	Ordered bool
+	Items   []*Item
+}
+
+// Item is a single @item of a list.
+type Item struct {
+	Pos
+	Text string
+}
+
+// AdmonitionKind identifies the directive that produced an Admonition.
+type AdmonitionKind int
+
+const (
+	AdmonitionInfo AdmonitionKind = iota
+	AdmonitionWarning
+	AdmonitionTip
+	AdmonitionNote
+	AdmonitionTodo
+)
+
+// Admonition is a highlighted one-line remark: @info, @warning, @tip, @note or @todo.
+type Admonition struct {
+	Pos
+	Kind AdmonitionKind
+	Text string
+}
+
+// ParamKind distinguishes @param from @return.
+type ParamKind int
+
+const (
+	ParamInput ParamKind = iota
+	ParamReturn
+)
+
+// ParamList is a @param or @return line, split at "|".
+type ParamList struct {
+	Pos
+	Kind   ParamKind
+	Values []string
+}
+
+// Deprecated marks the surrounding content as deprecated (@deprecated).
+type Deprecated struct {
+	Pos
+}
+
+// ToBeContinued is the @tbc placeholder for content that is still missing.
+type ToBeContinued struct {
+	Pos
+}
+
+// ExampleKind distinguishes @example from @usecase blocks.
+type ExampleKind int
+
+const (
+	ExampleBlock ExampleKind = iota
+	UseCaseBlock
+)
+
+// Example is an @example ... @endexample or @usecase ... @endusecase box.
+type Example struct {
+	Pos
+	Kind     ExampleKind
+	Children []Node
+}
+
+func (e *Example) appendChild(n Node) {
+	e.Children = append(e.Children, n)
+}
+
+// Internal is an @internal ... @endinternal block. Its content only appears in the
+// development documentation.
+type Internal struct {
+	Pos
+	Children []Node
+}
+
+func (i *Internal) appendChild(n Node) {
+	i.Children = append(i.Children, n)
+}
+
+// Reference is a @ref or @link line that links to a document or one of its sections,
+// e.g. "@ref install.fdl#configuration Configure the server".
+type Reference struct {
+	Pos
+	// Target is "file.fdl", "file.fdl#section" or "#section", relative to the document.
+	Target string
+	// Text is the optional link text.
+	Text string
+	// TargetDoc and Section are set by ResolveReferences. Section stays nil for
+	// references to a whole document.
+	TargetDoc *Document
+	Section   *Section
+}
+
+// Paragraph is a run of consecutive plain text lines.
+type Paragraph struct {
+	Pos
+	Lines []string
+}
+
+// BlankLine is an empty line outside of any block.
+type BlankLine struct {
+	Pos
+}
+
+// Walk calls fn for n and every node below it in document order.
+// If fn returns false the children of that node are skipped.
+func Walk(n Node, fn func(Node) bool) {
+	if !fn(n) {
+		return
+	}
+	var children []Node
+	switch n := n.(type) {
+	case *Document:
+		children = n.Children
+	case *Section:
+		children = n.Children
+	case *Example:
+		children = n.Children
+	case *Internal:
+		children = n.Children
+	case *Table:
+		for _, row := range n.Rows {
+			Walk(row, fn)
+		}
+	case *List:
+		for _, item := range n.Items {
+			Walk(item, fn)
+		}
+	}
+	for _, child := range children {
+		Walk(child, fn)
+	}
+}
@@ -0,0 +1,190 @@
+package fdl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// conditionValues are the values @if conditions are evaluated against. They are
+// set with --var and --tag or in the configuration file.
+type conditionValues struct {
+	Vars map[string]string
+	Tags []string
+	// All selects every branch, the @if and the @else branch alike, to check the markup
+	// of all of them.
+	All bool
+}
+
+// conditionTerm is a single part of an @if condition:
+//
+//	audience=internal         the variable has one of the values
+//	audience!=internal,partner the variable has none of the values
+//	beta                      the tag is set
+//	!beta                     the tag is not set
+type conditionTerm struct {
+	Key    string
+	Values []string
+	Negate bool
+}
+
+// parseCondition splits the argument of @if into terms. All terms must be true.
+func parseCondition(arg string) ([]conditionTerm, error) {
+	fields := strings.Fields(arg)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("@if without condition")
+	}
+	terms := make([]conditionTerm, 0, len(fields))
+	for _, field := range fields {
+		var term conditionTerm
+		key, values, isVar := strings.Cut(field, "=")
+		if isVar {
+			key, term.Negate = strings.CutSuffix(key, "!")
+			for _, value := range strings.Split(values, ",") {
+				if value == "" {
+					return nil, fmt.Errorf("condition %q has an empty value", field)
+				}
+				term.Values = append(term.Values, value)
+			}
+		} else {
+			key, term.Negate = strings.CutPrefix(key, "!")
+		}
+		if !isConditionName(key) {
+			return nil, fmt.Errorf("invalid condition %q", field)
+		}
+		term.Key = key
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+func isConditionName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func (t conditionTerm) eval(values conditionValues) bool {
+	var matched bool
+	if t.Values == nil {
+		for _, tag := range values.Tags {
+			matched = matched || tag == t.Key
+		}
+	} else {
+		value, ok := values.Vars[t.Key]
+		for _, expected := range t.Values {
+			matched = matched || ok && value == expected
+		}
+	}
+	return matched != t.Negate
+}
+
+func evalCondition(terms []conditionTerm, values conditionValues) bool {
+	for _, term := range terms {
+		if !term.eval(values) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseVar splits a "key=value" assignment of --var.
+func ParseVar(assignment string) (string, string, error) {
+	key, value, ok := strings.Cut(assignment, "=")
+	if !ok || !isConditionName(key) {
+		return "", "", fmt.Errorf("invalid variable %q, expected key=value", assignment)
+	}
+	return key, value, nil
+}
+
+// FormatVars returns the variables as sorted "key=value" assignments.
+func FormatVars(vars map[string]string) []string {
+	assignments := make([]string, 0, len(vars))
+	for key, value := range vars {
+		assignments = append(assignments, key+"="+value)
+	}
+	sort.Strings(assignments)
+	return assignments
+}
+
+// conditional is an open @if block of the parser.
+type conditional struct {
+	Pos
+	terms  []conditionTerm
+	inElse bool
+	// active is set while the lines of the current branch are kept.
+	active bool
+	// parentActive is set if the surrounding branch is kept.
+	parentActive bool
+}
+
+// skipping reports whether the current line is inside a branch that is left out.
+func (p *parser) skipping() bool {
+	return len(p.ifs) > 0 && !p.ifs[len(p.ifs)-1].active
+}
+
+// parseConditional handles @if, @else and @endif and reports whether line was one of them.
+// Lines of branches whose condition is false never reach the document tree, so a
+// condition may span sections and blocks.
+func (p *parser) parseConditional(name string, arg string, pos Pos) bool {
+	switch name {
+	case "@if":
+		parentActive := !p.skipping()
+		terms, err := parseCondition(arg)
+		if err != nil {
+			p.diags.Errorf(pos, CodeInvalidCondition, "%v", err)
+		}
+		p.ifs = append(p.ifs, &conditional{Pos: pos, terms: terms, parentActive: parentActive,
+			active: parentActive && err == nil && (p.values.All || evalCondition(terms, p.values))})
+	case "@else":
+		if len(p.ifs) == 0 {
+			p.diags.Warnf(pos, CodeStrayConditional, "@else without matching @if")
+			break
+		}
+		c := p.ifs[len(p.ifs)-1]
+		if c.inElse {
+			p.diags.Warnf(pos, CodeStrayConditional, "second @else for @if at line %d", c.Line)
+			break
+		}
+		c.inElse = true
+		c.active = c.parentActive && c.terms != nil && (p.values.All || !evalCondition(c.terms, p.values))
+	case "@endif":
+		if len(p.ifs) == 0 {
+			p.diags.Warnf(pos, CodeStrayConditional, "@endif without matching @if")
+			break
+		}
+		p.ifs = p.ifs[:len(p.ifs)-1]
+	default:
+		return false
+	}
+	p.para = nil
+	return true
+}
+
+// checkAllBranches parses source again with every @if and @else branch and adds the
+// problems to diags that are only found in the branches opts leaves out.
+func checkAllBranches(source []byte, opts Options, diags *Diagnostics) {
+	values := opts.conditionValues()
+	values.All = true
+	all := &Diagnostics{}
+	if _, err := parseFor(bytes.NewReader(source), opts.Path, values, all); err != nil {
+		return
+	}
+	reported := make(map[Diagnostic]bool, len(diags.list))
+	for _, d := range diags.list {
+		reported[d] = true
+	}
+	for _, d := range all.list {
+		if !reported[d] {
+			reported[d] = true
+			diags.list = append(diags.list, d)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package fdl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalCondition(t *testing.T) {
+	values := conditionValues{Vars: map[string]string{"audience": "internal"}, Tags: []string{"beta"}}
+	tests := []struct {
+		condition string
+		expected  bool
+	}{
+		{"audience=internal", true},
+		{"audience=external", false},
+		{"audience=partner,internal", true},
+		{"audience!=internal", false},
+		{"audience!=external", true},
+		{"edition=pro", false},
+		{"edition!=pro", true},
+		{"beta", true},
+		{"!beta", false},
+		{"preview", false},
+		{"audience=internal beta", true},
+		{"audience=internal preview", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			terms, err := parseCondition(tt.condition)
+			if err != nil {
+				t.Fatalf("parseCondition() failed: %v", err)
+			}
+			if got := evalCondition(terms, values); got != tt.expected {
+				t.Errorf("Expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseConditionErrors(t *testing.T) {
+	for _, condition := range []string{"", "=internal", "audience=", "audience=a,,b", "a b!c", "!"} {
+		if _, err := parseCondition(condition); err == nil {
+			t.Errorf("Expected an error for %q", condition)
+		}
+	}
+}
+
+func TestParseConditionalBlocks(t *testing.T) {
+	input := `@title Guide
+@if audience=internal
+@section Internal API
+Only for us.
+@if beta
+Beta feature.
+@endif
+@else
+@section Public API
+For everyone.
+@endif
+@section Common
+Shared text.
+`
+	tests := []struct {
+		values   conditionValues
+		expected []string
+		missing  []string
+	}{
+		{
+			values:   conditionValues{},
+			expected: []string{"Public API", "For everyone.", "Common"},
+			missing:  []string{"Internal API", "Only for us.", "Beta feature."},
+		},
+		{
+			values:   conditionValues{Vars: map[string]string{"audience": "internal"}},
+			expected: []string{"Internal API", "Only for us.", "Common"},
+			missing:  []string{"Public API", "Beta feature."},
+		},
+		{
+			values:   conditionValues{Vars: map[string]string{"audience": "internal"}, Tags: []string{"beta"}},
+			expected: []string{"Internal API", "Beta feature.", "Common"},
+			missing:  []string{"Public API"},
+		},
+	}
+
+	for _, tt := range tests {
+		diags := &Diagnostics{}
+		doc, err := parseFor(strings.NewReader(input), "test.fdl", tt.values, diags)
+		if err != nil {
+			t.Fatalf("parseFor() failed: %v", err)
+		}
+		if len(diags.list) > 0 {
+			t.Errorf("Expected no diagnostics, got %v", diags.Sorted())
+		}
+		html, _ := renderHTMLBody(doc)
+		for _, text := range tt.expected {
+			if !strings.Contains(html, text) {
+				t.Errorf("%+v: expected %q in %s", tt.values, text, html)
+			}
+		}
+		for _, text := range tt.missing {
+			if strings.Contains(html, text) {
+				t.Errorf("%+v: expected no %q in %s", tt.values, text, html)
+			}
+		}
+	}
+}
+
+func TestConditionalCodeBlocks(t *testing.T) {
+	// @if, @else and @endif inside @code are content, also in a branch that is left out.
+	input := "@if audience=internal\n@code\n@if beta\n@else\n@endcode\n@endif\nPUBLIC TEXT\n"
+	tests := []struct {
+		values   conditionValues
+		expected []string
+		missing  []string
+	}{
+		{
+			values:   conditionValues{},
+			expected: []string{"PUBLIC TEXT"},
+			missing:  []string{"@if beta", "@else"},
+		},
+		{
+			values:   conditionValues{Vars: map[string]string{"audience": "internal"}},
+			expected: []string{"@if beta", "@else", "PUBLIC TEXT"},
+		},
+	}
+
+	for _, tt := range tests {
+		diags := &Diagnostics{}
+		doc, err := parseFor(strings.NewReader(input), "test.fdl", tt.values, diags)
+		if err != nil {
+			t.Fatalf("parseFor() failed: %v", err)
+		}
+		if len(diags.list) > 0 {
+			t.Errorf("%+v: expected no diagnostics, got %v", tt.values, diags.Sorted())
+		}
+		html, _ := renderHTMLBody(doc)
+		for _, text := range tt.expected {
+			if !strings.Contains(html, text) {
+				t.Errorf("%+v: expected %q in %s", tt.values, text, html)
+			}
+		}
+		for _, text := range tt.missing {
+			if strings.Contains(html, text) {
+				t.Errorf("%+v: expected no %q in %s", tt.values, text, html)
+			}
+		}
+	}
+}
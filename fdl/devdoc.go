@@ -0,0 +1,31 @@
+package fdl
+
+// draftNotice is shown in the banner of the development documentation.
+const draftNotice = "Development documentation, not for publication."
+
+// stripDrafts removes @todo, @tbc and @internal content from doc. It prepares the
+// documents of a normal build, so the published documentation never shows open work.
+// The development documentation keeps everything and is marked as draft instead.
+func stripDrafts(doc *Document) {
+	doc.Children = stripDraftNodes(doc.Children)
+}
+
+func stripDraftNodes(nodes []Node) []Node {
+	kept := nodes[:0]
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *Internal, *ToBeContinued:
+			continue
+		case *Admonition:
+			if n.Kind == AdmonitionTodo {
+				continue
+			}
+		case *Section:
+			n.Children = stripDraftNodes(n.Children)
+		case *Example:
+			n.Children = stripDraftNodes(n.Children)
+		}
+		kept = append(kept, n)
+	}
+	return kept
+}
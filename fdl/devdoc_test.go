@@ -0,0 +1,55 @@
+package fdl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const draftInput = `@title Guide
+@todo Describe the setup
+@section Setup
+Run the installer.
+@tbc
+@internal
+Ask ops for the staging password.
+@endinternal
+@example
+@todo Better example
+@endexample
+`
+
+func TestStripDrafts(t *testing.T) {
+	doc := mustParse(t, draftInput)
+	stripDrafts(doc)
+
+	Walk(doc, func(n Node) bool {
+		switch n := n.(type) {
+		case *Internal, *ToBeContinued:
+			t.Errorf("Expected %T at %s to be removed", n, n.Position())
+		case *Admonition:
+			if n.Kind == AdmonitionTodo {
+				t.Errorf("Expected @todo at %s to be removed", n.Position())
+			}
+		}
+		return true
+	})
+
+	html, _ := renderHTMLBody(doc)
+	if !strings.Contains(html, "Run the installer.") || !strings.Contains(html, "Example:") {
+		t.Errorf("Expected the published content to be kept, got %s", html)
+	}
+}
+
+func TestPDFDraftBanner(t *testing.T) {
+	doc := mustParse(t, "@title Guide\n")
+
+	var out bytes.Buffer
+	if err := (pdfRenderer{site: Settings{Draft: true}}).RenderDocument(&out, doc); err != nil {
+		t.Fatalf("RenderDocument failed: %v", err)
+	}
+	checkPDFStructure(t, out.Bytes())
+	if !strings.Contains(out.String(), "(DRAFT ) Tj") {
+		t.Errorf("Expected a draft banner on the cover page")
+	}
+}
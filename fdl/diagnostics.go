@@ -0,0 +1,152 @@
+package fdl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Severity tells whether a diagnostic fails the build.
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Stable diagnostic codes. Codes below FDL100 are about the environment (files,
+// directories, options), codes from FDL100 on are about the markup of a document.
+// Never reuse or renumber a code, tools may match on them.
+const (
+	CodeFileSystem     = "FDL001" // input files can't be found or read
+	CodeOutput         = "FDL002" // output files or directories can't be written
+	CodeInvalidOption  = "FDL003" // invalid command line option or setting
+	CodeUnusedFragment = "FDL004" // fragment that no document includes
+	CodeOutputConflict = "FDL005" // two documents, or a document and the index, have the same output file
+
+	CodeStrayEndCode      = "FDL101" // @endcode without @code
+	CodeRowOutsideTable   = "FDL102" // @row outside of @table
+	CodeStrayEndTable     = "FDL103" // @endtable without @table
+	CodeItemOutsideList   = "FDL104" // @item outside of @list
+	CodeStrayEndList      = "FDL105" // @endlist without @list
+	CodeStrayEndExample   = "FDL106" // @endexample or @endusecase without an open block
+	CodeMismatchedEnd     = "FDL107" // @endexample closes @usecase or the other way round
+	CodeStrayEndInternal  = "FDL108" // @endinternal without @internal
+	CodeContentInList     = "FDL109" // text or a directive other than @item inside @list
+	CodeUnterminatedCode  = "FDL110" // @code is never closed
+	CodeUnterminatedTable = "FDL111" // @table is never closed
+	CodeUnterminatedList  = "FDL112" // @list is never closed
+	CodeUnterminatedBlock = "FDL113" // @example, @usecase, @internal or @if is never closed
+	CodeUnknownDirective  = "FDL114" // @directive that doesn't exist
+	CodeInvalidCondition  = "FDL115" // @if with a malformed condition
+	CodeDanglingReference = "FDL116" // @ref to a document or section that doesn't exist
+	CodeInclude           = "FDL117" // @include of a missing file or an include cycle
+	CodeStrayConditional  = "FDL118" // @else or @endif without @if, or a second @else
+
+	// The following codes are only reported by "fdl lint".
+	CodeDuplicateSection = "FDL120" // two sections with the same title
+	CodeMissingTitle     = "FDL121" // document without @title
+)
+
+// Diagnostic is a single problem found while building the documentation.
+type Diagnostic struct {
+	Pos      Pos
+	Severity Severity
+	Code     string
+	Message  string
+}
+
+// String formats the diagnostic like a compiler message: "path:line:col: error[FDL102]: message".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", d.Pos, d.Severity, d.Code, d.Message)
+}
+
+// Diagnostics collects the problems of a build.
+type Diagnostics struct {
+	list []Diagnostic
+}
+
+func (d *Diagnostics) add(pos Pos, severity Severity, code string, format string, args ...any) {
+	d.list = append(d.list, Diagnostic{Pos: pos, Severity: severity, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Errorf records an error. Errors make the program exit with a non-zero code.
+func (d *Diagnostics) Errorf(pos Pos, code string, format string, args ...any) {
+	d.add(pos, SeverityError, code, format, args...)
+}
+
+// Warnf records a warning.
+func (d *Diagnostics) Warnf(pos Pos, code string, format string, args ...any) {
+	d.add(pos, SeverityWarning, code, format, args...)
+}
+
+// Merge appends the diagnostics of other, e.g. those collected by a worker.
+func (d *Diagnostics) Merge(other *Diagnostics) {
+	d.list = append(d.list, other.list...)
+}
+
+// Len returns the number of errors and warnings.
+func (d *Diagnostics) Len() int {
+	return len(d.list)
+}
+
+// Error returns the errors, one per line, so Diagnostics can be returned as an error.
+func (d *Diagnostics) Error() string {
+	var errs []string
+	for _, diagnostic := range d.Sorted() {
+		if diagnostic.Severity == SeverityError {
+			errs = append(errs, diagnostic.String())
+		}
+	}
+	return strings.Join(errs, "\n")
+}
+
+// HasErrors reports whether at least one error was recorded.
+func (d *Diagnostics) HasErrors() bool {
+	for _, diagnostic := range d.list {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Sorted returns all diagnostics ordered by file, line and column.
+func (d *Diagnostics) Sorted() []Diagnostic {
+	sorted := append([]Diagnostic(nil), d.list...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Pos, sorted[j].Pos
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return sorted
+}
+
+// Print writes all diagnostics followed by a summary line.
+func (d *Diagnostics) Print(w io.Writer) {
+	errors, warnings := 0, 0
+	for _, diagnostic := range d.Sorted() {
+		fmt.Fprintln(w, diagnostic)
+		if diagnostic.Severity == SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	if errors+warnings > 0 {
+		fmt.Fprintf(w, "%d error(s), %d warning(s)\n", errors, warnings)
+	}
+}
@@ -0,0 +1,84 @@
+package fdl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParserDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"@endcode", []string{"test.fdl:1:1: warning[FDL101]: @endcode without matching @code"}},
+		{"@row a|b", []string{"test.fdl:1:1: error[FDL102]: @row outside of @table"}},
+		{"@endtable", []string{"test.fdl:1:1: warning[FDL103]: @endtable without matching @table"}},
+		{"@item a", []string{"test.fdl:1:1: warning[FDL104]: @item outside of @list is shown as plain text"}},
+		{"@endlist", []string{"test.fdl:1:1: warning[FDL105]: @endlist without matching @list"}},
+		{"@endusecase", []string{"test.fdl:1:1: warning[FDL106]: @endusecase without matching @usecase"}},
+		{"@list\n@item a\ntext", []string{"test.fdl:3:1: warning[FDL109]: content inside @list ends the list opened at line 1"}},
+		{"@example\n@endusecase", []string{"test.fdl:2:1: warning[FDL107]: @endusecase closes @example opened at line 1, expected @endexample"}},
+		{"text\n@code\nx", []string{"test.fdl:2:1: error[FDL110]: @code is never closed with @endcode"}},
+		{"@table\n@section Next", []string{"test.fdl:1:1: error[FDL111]: @table is never closed with @endtable"}},
+		{"@list\n@item a", []string{"test.fdl:1:1: error[FDL112]: @list is never closed with @endlist"}},
+		{"@usecase\n@example", []string{
+			"test.fdl:1:1: error[FDL113]: @usecase is never closed with @endusecase",
+			"test.fdl:2:1: error[FDL113]: @example is never closed with @endexample",
+		}},
+		{"@endinternal", []string{"test.fdl:1:1: warning[FDL108]: @endinternal without matching @internal"}},
+		{"@section A\n@internal\ntext", []string{"test.fdl:2:1: error[FDL113]: @internal is never closed with @endinternal"}},
+		{"@endif", []string{"test.fdl:1:1: warning[FDL118]: @endif without matching @if"}},
+		{"@if a\n@else\n@else\n@endif", []string{"test.fdl:3:1: warning[FDL118]: second @else for @if at line 1"}},
+		{"@if audience=\n@endif", []string{"test.fdl:1:1: error[FDL115]: condition \"audience=\" has an empty value"}},
+		{"@if beta\ntext", []string{"test.fdl:1:1: error[FDL113]: @if is never closed with @endif"}},
+		{"@list\n@item a\n@endlist\n@table\n@row a\n@endtable", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			diags := &Diagnostics{}
+			if _, err := parse(strings.NewReader(tt.input), "test.fdl", diags); err != nil {
+				t.Fatalf("parse() failed: %v", err)
+			}
+			got := diags.Sorted()
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %d diagnostics, got %v", len(tt.expected), got)
+			}
+			for i, diagnostic := range got {
+				if diagnostic.String() != tt.expected[i] {
+					t.Errorf("Expected %q, got %q", tt.expected[i], diagnostic.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDiagnosticsPrint(t *testing.T) {
+	diags := &Diagnostics{}
+	diags.Warnf(Pos{File: "b.fdl", Line: 3, Column: 1}, CodeStrayEndList, "second")
+	diags.Errorf(Pos{File: "a.fdl", Line: 7, Column: 1}, CodeRowOutsideTable, "first")
+	diags.Errorf(Pos{File: "documentation"}, CodeOutput, "third")
+
+	if !diags.HasErrors() {
+		t.Errorf("Expected HasErrors to be true")
+	}
+
+	var out bytes.Buffer
+	diags.Print(&out)
+	expected := "a.fdl:7:1: error[FDL102]: first\n" +
+		"b.fdl:3:1: warning[FDL105]: second\n" +
+		"documentation: error[FDL002]: third\n" +
+		"2 error(s), 1 warning(s)\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+}
+
+func TestDiagnosticsWarningsOnly(t *testing.T) {
+	diags := &Diagnostics{}
+	diags.Warnf(Pos{File: "a.fdl", Line: 1, Column: 1}, CodeStrayEndCode, "warning")
+	if diags.HasErrors() {
+		t.Errorf("Expected warnings not to count as errors")
+	}
+}
@@ -0,0 +1,145 @@
+// Package fdl converts documents written in the FastDocumentationLanguage into HTML,
+// Markdown and PDF. It is the converter behind the fdl command and can be embedded to
+// generate documentation from other Go programs:
+//
+//	doc, err := fdl.Convert(strings.NewReader("@title Guide\n@section Start\nHello"), fdl.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	return fdl.RenderHTML(w, doc)
+//
+// Convert reads a single document. Tools that convert several documents which link to
+// each other use Parse for every document and ResolveReferences for all of them, like
+// "fdl build" does, and render them with NewRenderer.
+package fdl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options control how a document is read.
+type Options struct {
+	// Path is the name of the document in diagnostics. The paths of @include and @ref are
+	// relative to its directory, which is relative to the working directory. Without a
+	// path they are relative to the working directory.
+	Path string
+	// Vars and Tags select the branches of @if blocks.
+	Vars map[string]string
+	Tags []string
+	// Draft keeps the @todo, @tbc and @internal content of the development documentation.
+	Draft bool
+	// Numbered prefixes every section heading with its number, e.g. 2.1.3.
+	Numbered bool
+	// AllBranches also reports the markup problems of the @if and @else branches that
+	// Vars and Tags leave out, like "fdl lint" does. The document only contains the
+	// selected branches.
+	AllBranches bool
+	// Diagnostics collects the errors and warnings found in the document. If it is nil,
+	// warnings are dropped and errors are only returned by Convert.
+	Diagnostics *Diagnostics
+}
+
+func (o Options) conditionValues() conditionValues {
+	return conditionValues{Vars: o.Vars, Tags: o.Tags}
+}
+
+// Parse reads a document from r without resolving its references, see ResolveReferences.
+// Malformed markup is reported to opts.Diagnostics, only read errors are returned.
+func Parse(r io.Reader, opts Options) (*Document, error) {
+	source, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	diags := opts.Diagnostics
+	if diags == nil {
+		diags = &Diagnostics{}
+	}
+	doc, err := parseFor(bytes.NewReader(source), opts.Path, opts.conditionValues(), diags)
+	if err != nil {
+		return nil, err
+	}
+	if opts.AllBranches {
+		checkAllBranches(source, opts, diags)
+	}
+	doc.Hash = HashSource(source)
+	if !opts.Draft {
+		stripDrafts(doc)
+	}
+	if opts.Numbered {
+		numberSections(doc)
+	}
+	return doc, nil
+}
+
+// Convert reads a single document from r and resolves its references. The documents it
+// refers to are read from disk, relative to opts.Path, to link to their titles and
+// sections, but they are not converted. If the document has errors, they are returned
+// as *Diagnostics.
+func Convert(r io.Reader, opts Options) (*Document, error) {
+	diags := &Diagnostics{}
+	if caller := opts.Diagnostics; caller != nil {
+		defer caller.Merge(diags)
+	}
+	opts.Diagnostics = diags
+	doc, err := Parse(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	documents := append([]*Document{doc}, referencedDocuments(doc, opts)...)
+	resolveDocumentReferences(doc, documentsByPath(documents), diags)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return doc, nil
+}
+
+// referencedDocuments reads the documents doc refers to. Their diagnostics are dropped,
+// they belong to the targets.
+func referencedDocuments(doc *Document, opts Options) []*Document {
+	var documents []*Document
+	seen := map[string]bool{filepath.ToSlash(filepath.Clean(doc.Path)): true}
+	Walk(doc, func(n Node) bool {
+		ref, ok := n.(*Reference)
+		if !ok {
+			return true
+		}
+		file, _, _ := strings.Cut(ref.Target, "#")
+		if file == "" || seen[referencePath(doc, file)] {
+			return true
+		}
+		targetPath := referencePath(doc, file)
+		seen[targetPath] = true
+		source, err := os.Open(filepath.FromSlash(targetPath))
+		if err != nil {
+			return true
+		}
+		defer source.Close()
+		targetOpts := opts
+		targetOpts.Path, targetOpts.Diagnostics = targetPath, nil
+		if target, err := Parse(source, targetOpts); err == nil {
+			documents = append(documents, target)
+		}
+		return true
+	})
+	return documents
+}
+
+// RenderHTML writes doc as an HTML page with the default theme, like "fdl build" does.
+// Use NewRenderer for the other formats and themes.
+func RenderHTML(w io.Writer, doc *Document) error {
+	return htmlRenderer{}.RenderDocument(w, doc)
+}
+
+// HashSource returns the SHA-256 of source as "sha256:<hex>". It ignores the line
+// endings, so a checkout with CRLF line endings has the same hash as one with LF line
+// endings.
+func HashSource(source []byte) string {
+	sum := sha256.Sum256(bytes.ReplaceAll(source, []byte("\r\n"), []byte("\n")))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,143 @@
+package fdl
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"docs/install.fdl": "@title Installation\n@section Configuration\n@todo Document the flags"})
+
+	input := "@title Guide\n@section Start\n@ref #start\n@ref install.fdl#configuration\n@if audience=internal\nInternal only\n@endif\n@todo Write more"
+	diags := &Diagnostics{}
+	doc, err := Convert(strings.NewReader(input), Options{Path: "docs/guide.fdl", Numbered: true, Diagnostics: diags})
+	if err != nil {
+		t.Fatalf("Convert() failed: %v", err)
+	}
+	if doc.Hash != HashSource([]byte(input)) {
+		t.Errorf("Expected the hash of the source, got %s", doc.Hash)
+	}
+
+	var out bytes.Buffer
+	if err := RenderHTML(&out, doc); err != nil {
+		t.Fatalf("RenderHTML() failed: %v", err)
+	}
+	html := out.String()
+	for _, expected := range []string{"<h2 id='start'>1 Start</h2>", "<a href='#start'>1 Start</a>", "<a href='install.html#configuration'>1 Configuration</a>"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, html)
+		}
+	}
+	for _, unexpected := range []string{"Internal only", "Write more"} {
+		if strings.Contains(html, unexpected) {
+			t.Errorf("Expected no %q in:\n%s", unexpected, html)
+		}
+	}
+	if diags.Len() != 0 {
+		t.Errorf("Expected no diagnostics, got %v", diags.Sorted())
+	}
+
+	// Mit Variablen und Entwurfsinhalten.
+	doc, err = Convert(strings.NewReader(input), Options{Path: "docs/guide.fdl", Vars: map[string]string{"audience": "internal"}, Draft: true})
+	if err != nil {
+		t.Fatalf("Convert() failed: %v", err)
+	}
+	out.Reset()
+	if err := RenderHTML(&out, doc); err != nil {
+		t.Fatalf("RenderHTML() failed: %v", err)
+	}
+	for _, expected := range []string{"Internal only", "Write more"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	diags := &Diagnostics{}
+	doc, err := Convert(strings.NewReader("@title Guide\n@ref missing.fdl\n@bogus"), Options{Path: "guide.fdl", Diagnostics: diags})
+	var findings *Diagnostics
+	if doc != nil || !errors.As(err, &findings) {
+		t.Fatalf("Expected *Diagnostics as error, got %v, %v", doc, err)
+	}
+	expected := "guide.fdl:2:1: error[FDL116]: @ref to unknown document missing.fdl"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+	// Warnungen landen nur in Options.Diagnostics.
+	if diags.Len() != 2 || findings.Len() != 2 {
+		t.Errorf("Expected the error and the warning, got %v", diags.Sorted())
+	}
+}
+
+func TestLineEndingsAreIgnored(t *testing.T) {
+	lf := "@title Guide\n@section Setup\n@code\nx := 1\n@endcode\n"
+	crlf := "@title Guide\r\n@section Setup\r\n@code\r\nx := 1\r\n@endcode\r\n"
+	if HashSource([]byte(lf)) != HashSource([]byte(crlf)) {
+		t.Errorf("Expected the same hash for LF and CRLF sources")
+	}
+
+	var outputs []string
+	for _, input := range []string{lf, crlf} {
+		var out bytes.Buffer
+		if err := (htmlRenderer{}).RenderDocument(&out, mustParse(t, input)); err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+		outputs = append(outputs, out.String())
+	}
+	if outputs[0] != outputs[1] {
+		t.Errorf("Expected the same output for LF and CRLF sources, got\n%s\nand\n%s", outputs[0], outputs[1])
+	}
+}
+
+func mustParse(t *testing.T, input string) *Document {
+	t.Helper()
+	doc, err := parse(strings.NewReader(input), "test.fdl", &Diagnostics{})
+	if err != nil {
+		t.Fatalf("parse() failed: %v", err)
+	}
+	return doc
+}
+
+// writeFiles legt die Dateien relativ zum aktuellen Verzeichnis an.
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatalf("Could not create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("Could not create %s: %v", name, err)
+		}
+	}
+}
+
+// inTempDir changes into a new temporary directory until the test ends.
+func inTempDir(t *testing.T) {
+	t.Helper()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(originalDir)
+	})
+}
+
+// parseFile parses the document at path with all drafts.
+func parseFile(path string, diags *Diagnostics) (*Document, error) {
+	file, err := os.Open(filepath.FromSlash(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file, Options{Path: path, Draft: true, Diagnostics: diags})
+}
@@ -0,0 +1,342 @@
+package fdl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// htmlRenderer is the default backend and produces standalone HTML pages.
+type htmlRenderer struct {
+	site Settings
+}
+
+func (htmlRenderer) Extension() string {
+	return ".html"
+}
+
+func (r htmlRenderer) RenderDocument(w io.Writer, doc *Document) error {
+	_, err := io.WriteString(w, r.draftBanner()+renderHTML(doc)+htmlThemes[r.site.Theme])
+	return err
+}
+
+func (r htmlRenderer) RenderIndex(w io.Writer, chapters []Chapter) error {
+	entries := indexEntries(chapters)
+	depths, items := make([]int, len(entries)), make([]string, len(entries))
+	for i, entry := range entries {
+		depths[i] = entry.Depth
+		if entry.Folder != "" {
+			items[i] = escapeHTML(entry.Folder)
+			continue
+		}
+		chapterFullName := strconv.Itoa(entry.Chapter.Number) + " " + escapeHTML(entry.Chapter.Name)
+		items[i] = " <a href='" + escapeAttribute(entry.Chapter.File) + "'>" + chapterFullName + "</a>"
+	}
+	table := "<html><body>" + r.draftBanner() + "<h1>" + escapeHTML(r.site.title()) + " <br> Table Of Content</h1>"
+	table = table + htmlNestedList(depths, items) + htmlThemes[r.site.Theme] + "</body></html>"
+	_, err := io.WriteString(w, table)
+	return err
+}
+
+func (r htmlRenderer) draftBanner() string {
+	if !r.site.Draft {
+		return ""
+	}
+	return "<div style='background-color:#fff3cd;padding:10px;border:2px dashed #ff9800;text-align:center;'>" +
+		"<strong>DRAFT</strong> " + draftNotice + "</div>\n"
+}
+
+func formatInfo(text string) string {
+	return fmt.Sprintf("<div style='background-color:#e7f3fe;padding:10px;border-left:6px solid #2196F3;'>"+
+		"<strong>Info:</strong> %s</div>", text)
+}
+
+func formatWarning(text string) string {
+	return fmt.Sprintf("<div style='background-color:#ffcccb;padding:10px;border-left:6px solid #f44336;'>"+
+		"<strong>Warning:</strong> %s</div>", text)
+}
+
+func formatTip(text string) string {
+	return fmt.Sprintf("<div style='background-color:#8fbc8f;padding:10px;border-left:6px solid #6e8b3d;'>"+
+		"<strong>Tip:</strong> %s</div>", text)
+}
+
+// generateTableOfContents renders sections as nested lists that mirror their hierarchy.
+func generateTableOfContents(sections []*Section) string {
+	if len(sections) == 0 {
+		return ""
+	}
+	items := make([]string, len(sections))
+	for i, section := range sections {
+		items[i] = fmt.Sprintf("<a href='#%s'>%s</a>", section.ID, escapeHTML(section.Heading()))
+	}
+	return "<h2>Table of Contents</h2>" + htmlNestedList(tocDepths(sections), items)
+}
+
+// htmlNestedList renders items as nested <ul> lists. depths holds the nesting depth of
+// every item, starting at 0 and growing by at most one from one item to the next.
+func htmlNestedList(depths []int, items []string) string {
+	var list strings.Builder
+	previous := -1
+	for i, depth := range depths {
+		if depth > previous {
+			list.WriteString("<ul>")
+		} else {
+			list.WriteString("</li>" + strings.Repeat("</ul></li>", previous-depth))
+		}
+		list.WriteString("<li>" + items[i])
+		previous = depth
+	}
+	if previous >= 0 {
+		list.WriteString("</li>" + strings.Repeat("</ul></li>", previous) + "</ul>")
+	}
+	return list.String()
+}
+
+func escapeHTML(input string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(input, "<", "&lt;"), ">", "&gt;")
+}
+
+// escapeAttribute escapes s for an attribute value in single or double quotes.
+func escapeAttribute(s string) string {
+	return strings.NewReplacer("&", "&amp;", "'", "&#39;", "\"", "&quot;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// renderInlineHTML converts the inline markup of text to HTML and escapes everything else.
+func renderInlineHTML(text string) string {
+	var b strings.Builder
+	spans := parseInline(text)
+	for i := 0; i < len(spans); {
+		// Consecutive spans of the same link share one <a> element.
+		url := spans[i].URL
+		end := i
+		for end < len(spans) && spans[end].URL == url {
+			end++
+		}
+		if url != "" {
+			b.WriteString("<a href='" + escapeAttribute(url) + "'>")
+		}
+		for _, span := range spans[i:end] {
+			s := escapeHTML(span.Text)
+			if span.Code {
+				s = "<code>" + s + "</code>"
+			}
+			if span.Italic {
+				s = "<em>" + s + "</em>"
+			}
+			if span.Bold {
+				s = "<strong>" + s + "</strong>"
+			}
+			b.WriteString(s)
+		}
+		if url != "" {
+			b.WriteString("</a>")
+		}
+		i = end
+	}
+	return b.String()
+}
+
+func processStyling() string {
+	return "<style>.example-box {border: 2px solid black;padding: 10px;margin: 20px 0;" +
+		"border-radius: 5px;background-color: #f9f9f9;position: relative;overflow: hidden;}" +
+		".example-title {font-weight: bold;margin: 0;padding: 5px 10px;background-color: #e0e0e0;" +
+		"border-bottom: 2px solid black;position: absolute;top: 0;left: 0;width: 100%;box-sizing: border-box;}" +
+		".example-content {padding-top: 40px;}</style>"
+}
+
+// htmlThemes are the stylesheets selectable with the theme setting. They follow the
+// default styling, so a theme only overrides what it changes.
+var htmlThemes = map[string]string{
+	"default": "",
+	"dark": "<style>body {background-color: #1e1e1e;color: #d4d4d4;}a {color: #4fc1ff;}" +
+		".example-box {background-color: #252526;border-color: #d4d4d4;}" +
+		".example-title {background-color: #333333;border-color: #d4d4d4;}" +
+		"div[style] {color: #1e1e1e;}</style>",
+}
+
+// ThemeNames returns the names of all HTML themes, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(htmlThemes))
+	for name := range htmlThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// htmlWriter renders a Document as HTML, one output line per emitted fragment.
+type htmlWriter struct {
+	doc      *Document
+	out      strings.Builder
+	sections []*Section
+}
+
+// renderHTML renders a complete HTML page for doc, including the table of contents and styling.
+func renderHTML(doc *Document) string {
+	body, sections := renderHTMLBody(doc)
+	toc := generateTableOfContents(sections)
+	return strings.Replace(body, "<h1>", "<h1>"+toc+"\n", 1) + processStyling()
+}
+
+// renderHTMLBody renders the nodes of doc and returns the sections found on the way.
+func renderHTMLBody(doc *Document) (string, []*Section) {
+	w := &htmlWriter{doc: doc}
+	w.nodes(doc.Children, false)
+	return w.out.String(), w.sections
+}
+
+func (w *htmlWriter) line(s string) {
+	if s == "" {
+		return
+	}
+	w.out.WriteString(s)
+	w.out.WriteString("\n")
+}
+
+func (w *htmlWriter) nodes(nodes []Node, inExample bool) {
+	for _, n := range nodes {
+		w.node(n, inExample)
+	}
+}
+
+func (w *htmlWriter) node(n Node, inExample bool) {
+	switch n := n.(type) {
+	case *Metadata:
+		w.metadata(n)
+	case *Abstract:
+		w.line("<h2>Abstract</h2><p>")
+	case *Section:
+		w.sections = append(w.sections, n)
+		w.line(fmt.Sprintf("<h%d id='%s'>%s</h%d>", n.Level+1, n.ID, escapeHTML(n.Heading()), n.Level+1))
+		w.nodes(n.Children, inExample)
+	case *Admonition:
+		w.admonition(n)
+	case *CodeBlock:
+		w.codeBlock(n, inExample)
+	case *Table:
+		w.line("<table border='1'>")
+		for _, row := range n.Rows {
+			w.row(row)
+		}
+		w.line("</table>")
+	case *Row:
+		w.row(n)
+	case *List:
+		w.list(n)
+	case *ParamList:
+		w.paramList(n)
+	case *Reference:
+		w.line(fmt.Sprintf("<p><a href='%s'>%s</a></p>", escapeAttribute(n.href(w.doc, htmlRenderer{}.Extension())), escapeHTML(n.linkText())))
+	case *Deprecated:
+		w.line("<strong><em style='color:red;'>Deprecated!</em></strong>")
+	case *Example:
+		title := "Example:"
+		if n.Kind == UseCaseBlock {
+			title = "UseCase:"
+		}
+		w.box(title, n.Children, true)
+	case *Internal:
+		w.box("Internal:", n.Children, inExample)
+	case *Paragraph:
+		for _, l := range n.Lines {
+			w.line(renderInlineHTML(l) + "<br>")
+		}
+	case *BlankLine:
+		w.line("<br>")
+	case *ToBeContinued:
+		w.line("<p><em>To be continued ...</em></p>")
+	}
+}
+
+// box renders nodes inside a titled box, like the content of @example or @internal.
+func (w *htmlWriter) box(title string, nodes []Node, inExample bool) {
+	w.line("<div class='example-box'><div class='example-title'>" + title + "</div><div class='example-content'>")
+	w.nodes(nodes, inExample)
+	w.line("</div></div>")
+}
+
+func (w *htmlWriter) metadata(m *Metadata) {
+	value := escapeHTML(m.Value)
+	switch m.Kind {
+	case MetaTitle:
+		w.line(fmt.Sprintf("<h1>%s</h1>", value))
+	case MetaAuthor:
+		w.line(fmt.Sprintf("<p>Author: %s</p>", value))
+	case MetaDate:
+		w.line(fmt.Sprintf("<p>Date: %s</p>", value))
+	case MetaVersion:
+		w.line(fmt.Sprintf("<p><em>Version:</em> %s</p>", value))
+	case MetaSince:
+		w.line(fmt.Sprintf("<p><em>Since:</em> %s</p>", value))
+	}
+}
+
+func (w *htmlWriter) admonition(a *Admonition) {
+	text := renderInlineHTML(a.Text)
+	switch a.Kind {
+	case AdmonitionInfo:
+		w.line(formatInfo(text))
+	case AdmonitionWarning:
+		w.line(formatWarning(text))
+	case AdmonitionTip:
+		w.line(formatTip(text))
+	case AdmonitionNote:
+		w.line(fmt.Sprintf("<p><em>Note:</em> %s</p>", text))
+	case AdmonitionTodo:
+		w.line(fmt.Sprintf("<p><em>TODO:</em> %s</p>", text))
+	}
+}
+
+func (w *htmlWriter) codeBlock(c *CodeBlock, inExample bool) {
+	if inExample {
+		w.line("<pre><code>")
+	} else {
+		w.line("<div class='example-box'><div class='example-title'>Code:</div><div class='example-content'><pre><code>")
+	}
+	for _, l := range c.Lines {
+		w.line(escapeHTML(l) + "\n")
+	}
+	if inExample {
+		w.line("</code></pre>")
+	} else {
+		w.line("</code></pre></div></div>")
+	}
+}
+
+func (w *htmlWriter) row(r *Row) {
+	var rowBuilder strings.Builder
+	rowBuilder.WriteString("<tr>")
+	for _, cell := range r.Cells {
+		rowBuilder.WriteString(fmt.Sprintf("<td>%s</td>", renderInlineHTML(cell)))
+	}
+	rowBuilder.WriteString("</tr>")
+	w.line(rowBuilder.String())
+}
+
+func (w *htmlWriter) list(l *List) {
+	tag := "ul"
+	if l.Ordered {
+		tag = "ol"
+	}
+	w.line("<" + tag + ">")
+	for _, item := range l.Items {
+		w.line(fmt.Sprintf("<li>%s</li>", renderInlineHTML(item.Text)))
+	}
+	w.line("</" + tag + ">")
+}
+
+func (w *htmlWriter) paramList(p *ParamList) {
+	var rowBuilder strings.Builder
+	if p.Kind == ParamReturn {
+		rowBuilder.WriteString("<p><b>Return:</b></p>")
+	} else {
+		rowBuilder.WriteString("<p><b>Parameters</b></p>")
+	}
+	for _, value := range p.Values {
+		rowBuilder.WriteString(fmt.Sprintf("<p>%s</p>", escapeHTML(value)))
+	}
+	w.line(rowBuilder.String())
+}
@@ -0,0 +1,175 @@
+package fdl
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFormatInfo(t *testing.T) {
+	input := "This is an info message."
+	expected := "<div style='background-color:#e7f3fe;padding:10px;border-left:6px solid #2196F3;'><strong>Info:" +
+		"</strong> This is an info message.</div>"
+	result := formatInfo(input)
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestFormatWarning(t *testing.T) {
+	input := "This is a warning message."
+	expected := "<div style='background-color:#ffcccb;padding:10px;border-left:6px solid #f44336;'><strong>Warning:" +
+		"</strong> This is a warning message.</div>"
+	result := formatWarning(input)
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	} else {
+		fmt.Println(result != expected)
+	}
+}
+
+func TestRenderSection(t *testing.T) {
+	doc := mustParse(t, "@section Introduction")
+	expected := "<h2 id='introduction'>Introduction</h2>\n"
+	result, sections := renderHTMLBody(doc)
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+	if len(sections) != 1 || sections[0].Title != "Introduction" {
+		t.Errorf("Expected section 'Introduction', got %v", sections)
+	}
+}
+
+func TestRenderDefaultLine(t *testing.T) {
+	input := "This is a regular line."
+	expected := "This is a regular line.<br>\n"
+	result, _ := renderHTMLBody(mustParse(t, input))
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	// Test within code block
+	input = "@code\n    code block line\n@endcode"
+	expected = "<div class='example-box'><div class='example-title'>Code:</div><div class='example-content'><pre><code>\n" +
+		"    code block line\n\n</code></pre></div></div>\n"
+	result, _ = renderHTMLBody(mustParse(t, input))
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	// Test within table (should be dropped)
+	input = "@table\n| Table | Line |\n@endtable"
+	expected = "<table border='1'>\n</table>\n"
+	result, _ = renderHTMLBody(mustParse(t, input))
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestGenerateTableOfContents(t *testing.T) {
+	sections := []*Section{
+		{Level: 1, Title: "Introduction", ID: "section-1"},
+		{Level: 1, Title: "Details", ID: "section-2"},
+	}
+	expected := "<h2>Table of Contents</h2><ul><li><a href='#section-1'>Introduction</a></li><li>" +
+		"<a href='#section-2'>Details</a></li></ul>"
+	result := generateTableOfContents(sections)
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestEscapeHTML(t *testing.T) {
+	input := "<div>Example</div>"
+	expected := "&lt;div&gt;Example&lt;/div&gt;"
+	result := escapeHTML(input)
+	if result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestRenderHTMLDirectives(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+	}{
+		// @title
+		{"@title My Title", "<h1>My Title</h1>"},
+
+		// @author
+		{"@author John Doe", "<p>Author: John Doe</p>"},
+
+		// @date
+		{"@date 2024-08-25", "<p>Date: 2024-08-25</p>"},
+
+		// @abstract
+		{"@abstract", "<h2>Abstract</h2><p>"},
+
+		// @info
+		{"@info Information", formatInfo("Information")},
+
+		// @warning
+		{"@warning Warning Message", formatWarning("Warning Message")},
+
+		// @note
+		{"@note This is a note", "<p><em>Note:</em> This is a note</p>"},
+
+		// @code and @endcode
+		{"@code\n@endcode", "<div class='example-box'><div class='example-title'>Code:</div><div class='example-content'><pre><code>\n</code></pre></div></div>"},
+		{"@example\n@code\n@endcode\n@endexample", "<div class='example-box'><div class='example-title'>Example:</div><div class='example-content'>\n<pre><code>\n</code></pre>\n</div></div>"},
+
+		// @tbc
+		{"@tbc", "<p><em>To be continued ...</em></p>"},
+
+		// @table and @row
+		{"@table\n@row cell1|cell2|cell3\n@endtable", "<table border='1'>\n<tr><td>cell1</td><td>cell2</td><td>cell3</td></tr>\n</table>"},
+
+		// @version
+		{"@version 1.0.0", "<p><em>Version:</em> 1.0.0</p>"},
+
+		// @since
+		{"@since 2024", "<p><em>Since:</em> 2024</p>"},
+
+		// @deprecated
+		{"@deprecated", "<strong><em style='color:red;'>Deprecated!</em></strong>"},
+
+		// @param
+		{"@param param1|param2", "<p><b>Parameters</b></p><p>param1</p><p>param2</p>"},
+
+		// @return
+		{"@return return1|return2", "<p><b>Return:</b></p><p>return1</p><p>return2</p>"},
+
+		// @list, @item and @endlist
+		{"@list -n\n@item List item\n@endlist", "<ol>\n<li>List item</li>\n</ol>"},
+		{"@list\n@item List item\n@endlist", "<ul>\n<li>List item</li>\n</ul>"},
+		// Text inside a list ends it, so the output keeps the order of the source.
+		{"@list\n@item a\ntext between\n@info b", "<ul>\n<li>a</li>\n</ul>\ntext between<br>\n" + formatInfo("b")},
+
+		// @tip
+		{"@tip This is a tip", formatTip("This is a tip")},
+
+		// @todo
+		{"@todo This is a todo", "<p><em>TODO:</em> This is a todo</p>"},
+
+		// @example
+		{"@example\n@endexample", "<div class='example-box'><div class='example-title'>Example:</div><div class='example-content'>\n</div></div>"},
+
+		// @usecase
+		{"@usecase\n@endusecase", "<div class='example-box'><div class='example-title'>UseCase:</div><div class='example-content'>\n</div></div>"},
+
+		// unknown directives are kept as text
+		{"@unknown directive", "@unknown directive<br>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, _ := renderHTMLBody(mustParse(t, tt.input))
+			expected := tt.expectedOutput
+			if expected != "" {
+				expected += "\n"
+			}
+			if got != expected {
+				t.Errorf("renderHTMLBody() = %q, want %q", got, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package fdl
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IsFragment reports whether the file at path is only meant to be included. Fragments
+// start with "_", e.g. _support.fdl, and are not converted on their own.
+func IsFragment(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), "_")
+}
+
+// parseInclude handles "@include path". The lines of the included file are parsed as
+// if they were written in place of the directive, with their own file and line in
+// diagnostics. The path is relative to the file that contains the directive.
+func (p *parser) parseInclude(target string, pos Pos) {
+	if target == "" {
+		p.diags.Errorf(pos, CodeInclude, "@include without path")
+		return
+	}
+	file := path.Join(path.Dir(filepath.ToSlash(pos.File)), filepath.ToSlash(target))
+	if filepath.IsAbs(target) {
+		file = filepath.ToSlash(filepath.Clean(target))
+	}
+	for i, including := range p.including {
+		if including == file {
+			chain := append(append([]string(nil), p.including[i:]...), file)
+			p.diags.Errorf(pos, CodeInclude, "@include cycle: %s", strings.Join(chain, " -> "))
+			return
+		}
+	}
+
+	source, err := os.ReadFile(filepath.FromSlash(file))
+	if err != nil {
+		p.diags.Errorf(pos, CodeInclude, "can't include %s: %v", target, err)
+		return
+	}
+	if p.doc.Includes == nil {
+		p.doc.Includes = make(map[string]string)
+	}
+	p.doc.Includes[file] = HashSource(source)
+
+	p.including = append(p.including, file)
+	defer func() { p.including = p.including[:len(p.including)-1] }()
+	if err := p.parseLines(strings.NewReader(string(source)), file); err != nil {
+		p.diags.Errorf(pos, CodeInclude, "can't include %s: %v", target, err)
+	}
+}
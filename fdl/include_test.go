@@ -0,0 +1,82 @@
+package fdl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInclude(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"docs/guide.fdl":          "@title Guide\n@section Support\n@include parts/_contact.fdl\nAfter the include",
+		"docs/parts/_contact.fdl": "Mail support@example.com\n@include _license.fdl",
+		"docs/parts/_license.fdl": "@if audience=internal\n@include _missing.fdl\n@endif\n@note MIT licensed\n@bogus",
+	})
+
+	diags := &Diagnostics{}
+	doc, err := parseFile("docs/guide.fdl", diags)
+	if err != nil {
+		t.Fatalf("parseFile() failed: %v", err)
+	}
+
+	section := doc.Children[1].(*Section)
+	var got []string
+	for _, child := range section.Children {
+		switch n := child.(type) {
+		case *Paragraph:
+			got = append(got, fmt.Sprintf("%s %s", n.Pos, strings.Join(n.Lines, "|")))
+		case *Admonition:
+			got = append(got, fmt.Sprintf("%s %s", n.Pos, n.Text))
+		}
+	}
+	expected := []string{
+		"docs/parts/_contact.fdl:1:1 Mail support@example.com",
+		"docs/parts/_license.fdl:4:1 MIT licensed",
+		"docs/parts/_license.fdl:5:1 @bogus",
+		"docs/guide.fdl:4:1 After the include",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Die Meldung zeigt auf die Datei und Zeile, in der die Direktive wirklich steht.
+	if sorted := diags.Sorted(); len(sorted) != 1 || !strings.HasPrefix(sorted[0].String(), "docs/parts/_license.fdl:5:1: warning[FDL114]") {
+		t.Errorf("Expected one warning in _license.fdl, got %v", sorted)
+	}
+	if len(doc.Includes) != 2 || doc.Includes["docs/parts/_license.fdl"] == "" {
+		t.Errorf("Expected the hashes of both fragments, got %v", doc.Includes)
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"self.fdl":    "@include self.fdl",
+		"dot.fdl":     "@include ./dot.fdl",
+		"a.fdl":       "text\n@include sub/_b.fdl",
+		"sub/_b.fdl":  "@include ../a.fdl",
+		"missing.fdl": "@include _missing.fdl",
+		"empty.fdl":   "@include",
+	})
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"self.fdl", "self.fdl:1:1: error[FDL117]: @include cycle: self.fdl -> self.fdl"},
+		{"./dot.fdl", "./dot.fdl:1:1: error[FDL117]: @include cycle: dot.fdl -> dot.fdl"},
+		{"a.fdl", "sub/_b.fdl:1:1: error[FDL117]: @include cycle: a.fdl -> sub/_b.fdl -> a.fdl"},
+		{"missing.fdl", "missing.fdl:1:1: error[FDL117]: can't include _missing.fdl"},
+		{"empty.fdl", "empty.fdl:1:1: error[FDL117]: @include without path"},
+	}
+	for _, tt := range tests {
+		diags := &Diagnostics{}
+		if _, err := parseFile(tt.file, diags); err != nil {
+			t.Fatalf("parseFile() failed: %v", err)
+		}
+		if sorted := diags.Sorted(); len(sorted) != 1 || !strings.HasPrefix(sorted[0].String(), tt.expected) {
+			t.Errorf("%s: Expected %s, got %v", tt.file, tt.expected, sorted)
+		}
+	}
+}
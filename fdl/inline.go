@@ -0,0 +1,161 @@
+package fdl
+
+import "strings"
+
+// inlineSpan is a piece of text with a single inline style. Text is never escaped,
+// every renderer escapes it for its own output format.
+type inlineSpan struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Code   bool
+	// URL is the target of a [text](url) link, empty outside of links.
+	URL string
+}
+
+// parseInline splits text into styled spans. The inline markup is
+//
+//	*bold*  _italic_  `code`  [text](url)
+//
+// Bold, italic and links may be nested, code is taken literally. A backslash
+// escapes a markup character and markup that is never closed stays plain text.
+func parseInline(text string) []inlineSpan {
+	var spans []inlineSpan
+	parseInlineInto(&spans, text, inlineSpan{})
+	return spans
+}
+
+func parseInlineInto(spans *[]inlineSpan, text string, style inlineSpan) {
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			span := style
+			span.Text = plain.String()
+			appendSpan(spans, span)
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte(inlineMarkers, text[i+1]) >= 0:
+			plain.WriteByte(text[i+1])
+			i += 2
+			continue
+		case c == '`':
+			if end := strings.IndexByte(text[i+1:], '`'); end > 0 {
+				flush()
+				span := style
+				span.Code, span.Text = true, text[i+1:i+1+end]
+				appendSpan(spans, span)
+				i += end + 2
+				continue
+			}
+		case c == '*' || c == '_':
+			if end := closingDelimiter(text, i); end > 0 {
+				flush()
+				inner := style
+				if c == '*' {
+					inner.Bold = true
+				} else {
+					inner.Italic = true
+				}
+				parseInlineInto(spans, text[i+1:end], inner)
+				i = end + 1
+				continue
+			}
+		case c == '[' && style.URL == "":
+			if label, url, n := parseLink(text[i:]); n > 0 {
+				flush()
+				inner := style
+				inner.URL = url
+				parseInlineInto(spans, label, inner)
+				i += n
+				continue
+			}
+		}
+		plain.WriteByte(c)
+		i++
+	}
+	flush()
+}
+
+// inlineMarkers are the characters that can be escaped with a backslash.
+const inlineMarkers = "\\*_`[]()"
+
+// appendSpan adds span to spans, merging it into the last span if both have the same
+// style. Code spans are kept apart, like they were written.
+func appendSpan(spans *[]inlineSpan, span inlineSpan) {
+	if n := len(*spans); n > 0 && !span.Code {
+		last := &(*spans)[n-1]
+		if last.Bold == span.Bold && last.Italic == span.Italic && last.Code == span.Code && last.URL == span.URL {
+			last.Text += span.Text
+			return
+		}
+	}
+	*spans = append(*spans, span)
+}
+
+// closingDelimiter returns the index of the "*" or "_" that closes the one at text[open],
+// or -1. Like in Markdown the delimiters must touch the enclosed text and must not be
+// inside a word, so snake_case_names and 2*3*4 stay plain text.
+func closingDelimiter(text string, open int) int {
+	c := text[open]
+	if open+1 >= len(text) || text[open+1] == ' ' || (open > 0 && isWordChar(text[open-1])) {
+		return -1
+	}
+	for i := open + 2; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		if text[i] == c && text[i-1] != ' ' && (i+1 == len(text) || !isWordChar(text[i+1])) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// parseLink parses "[label](url)" at the start of text and returns the length of the
+// link, or 0 if text does not start with a link.
+func parseLink(text string) (label string, url string, n int) {
+	end := -1
+	for i := 1; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+		} else if text[i] == ']' {
+			end = i
+			break
+		}
+	}
+	if end < 2 || end+1 >= len(text) || text[end+1] != '(' {
+		return "", "", 0
+	}
+	closing := strings.IndexByte(text[end+2:], ')')
+	if closing < 0 {
+		return "", "", 0
+	}
+	url = strings.TrimSpace(text[end+2 : end+2+closing])
+	if url == "" || strings.ContainsAny(url, " \t") || !isSafeURL(url) {
+		return "", "", 0
+	}
+	return text[1:end], url, end + 3 + closing
+}
+
+// isSafeURL rejects URLs with schemes like javascript: that a browser would execute.
+func isSafeURL(url string) bool {
+	scheme, _, ok := strings.Cut(url, ":")
+	if !ok || strings.ContainsAny(scheme, "/?#") {
+		return true
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "mailto", "ftp":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package fdl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInline(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []inlineSpan
+	}{
+		{"plain <text>", []inlineSpan{{Text: "plain <text>"}}},
+		{"a *bold* word", []inlineSpan{{Text: "a "}, {Text: "bold", Bold: true}, {Text: " word"}}},
+		{"_italic_", []inlineSpan{{Text: "italic", Italic: true}}},
+		{"*bold _both_*", []inlineSpan{{Text: "bold ", Bold: true}, {Text: "both", Bold: true, Italic: true}}},
+		{"run `go *test*`", []inlineSpan{{Text: "run "}, {Text: "go *test*", Code: true}}},
+		{"see [the *docs*](https://example.com)", []inlineSpan{
+			{Text: "see "}, {Text: "the ", URL: "https://example.com"}, {Text: "docs", Bold: true, URL: "https://example.com"},
+		}},
+		{"snake_case_name and 2*3*4", []inlineSpan{{Text: "snake_case_name and 2*3*4"}}},
+		{"* not bold *", []inlineSpan{{Text: "* not bold *"}}},
+		{"unclosed *bold and `code", []inlineSpan{{Text: "unclosed *bold and `code"}}},
+		{`\*escaped\* \[x\](y)`, []inlineSpan{{Text: "*escaped* [x](y)"}}},
+		{"[bad](javascript:alert(1))", []inlineSpan{{Text: "[bad](javascript:alert(1))"}}},
+		{"[relative](install.html#setup)", []inlineSpan{{Text: "relative", URL: "install.html#setup"}}},
+		{"[](empty) [x]", []inlineSpan{{Text: "[](empty) [x]"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := parseInline(tt.input)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderInline(t *testing.T) {
+	tests := []struct {
+		input    string
+		html     string
+		markdown string
+	}{
+		{"a <b> *bold*", "a &lt;b&gt; <strong>bold</strong>", "a \\<b\\> **bold**"},
+		{"_it_ and `x<y`", "<em>it</em> and <code>x&lt;y</code>", "*it* and `x<y`"},
+		{"`a` `b`", "<code>a</code> <code>b</code>", "`a` `b`"},
+		{"[*go* home](https://go.dev/?a=1&b='2')", "<a href='https://go.dev/?a=1&amp;b=&#39;2&#39;'><strong>go</strong> home</a>", "[**go** home](https://go.dev/?a=1&b='2')"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := renderInlineHTML(tt.input); got != tt.html {
+				t.Errorf("Expected HTML %s, got %s", tt.html, got)
+			}
+			if got := renderInlineMarkdown(tt.input); got != tt.markdown {
+				t.Errorf("Expected Markdown %s, got %s", tt.markdown, got)
+			}
+		})
+	}
+}
+
+func TestInlineRuns(t *testing.T) {
+	got := inlineRuns("*_both_* `code` [site](https://example.com) [https://example.com](https://example.com)")
+	expected := []pdfRun{
+		{fontBoldItalic, "both"}, {fontRegular, " "}, {fontMono, "code"}, {fontRegular, " "},
+		{fontRegular, "site"}, {fontRegular, " (https://example.com)"}, {fontRegular, " "},
+		{fontRegular, "https://example.com"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
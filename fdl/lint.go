@@ -0,0 +1,26 @@
+package fdl
+
+// Lint runs the checks that go beyond what the parser reports while building.
+func Lint(doc *Document, diags *Diagnostics) {
+	hasTitle := false
+	// Sections are keyed by the slug of their title, as their IDs are already unique.
+	sections := make(map[string]*Section)
+	Walk(doc, func(n Node) bool {
+		switch n := n.(type) {
+		case *Metadata:
+			if n.Kind == MetaTitle {
+				hasTitle = true
+			}
+		case *Section:
+			if first, ok := sections[slug(n.Title)]; ok {
+				diags.Warnf(n.Pos, CodeDuplicateSection, "section %q has the same title as the section at line %d, its id is %q", n.Title, first.Line, n.ID)
+			} else {
+				sections[slug(n.Title)] = n
+			}
+		}
+		return true
+	})
+	if !hasTitle {
+		diags.Warnf(Pos{File: doc.Path, Line: 1, Column: 1}, CodeMissingTitle, "document has no @title")
+	}
+}
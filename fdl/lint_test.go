@@ -0,0 +1,31 @@
+package fdl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintDocument(t *testing.T) {
+	input := "@section Intro\n@foo bar\n@section Intro\n"
+	diags := &Diagnostics{}
+	doc, err := parse(strings.NewReader(input), "test.fdl", diags)
+	if err != nil {
+		t.Fatalf("parse() failed: %v", err)
+	}
+	Lint(doc, diags)
+
+	expected := []string{
+		"test.fdl:1:1: warning[FDL121]: document has no @title",
+		"test.fdl:2:1: warning[FDL114]: unknown directive @foo is shown as plain text",
+		"test.fdl:3:1: warning[FDL120]: section \"Intro\" has the same title as the section at line 1, its id is \"intro-1\"",
+	}
+	got := diags.Sorted()
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d diagnostics, got %v", len(expected), got)
+	}
+	for i, diagnostic := range got {
+		if diagnostic.String() != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], diagnostic.String())
+		}
+	}
+}
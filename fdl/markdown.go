@@ -0,0 +1,352 @@
+package fdl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// markdownRenderer produces CommonMark/GFM files that render natively on code hosting platforms.
+type markdownRenderer struct {
+	site Settings
+}
+
+func (markdownRenderer) Extension() string {
+	return ".md"
+}
+
+func (r markdownRenderer) RenderDocument(w io.Writer, doc *Document) error {
+	_, err := io.WriteString(w, r.draftBanner()+renderMarkdown(doc))
+	return err
+}
+
+func (r markdownRenderer) RenderIndex(w io.Writer, chapters []Chapter) error {
+	var index strings.Builder
+	index.WriteString(r.draftBanner() + "# " + escapeMarkdown(r.site.title()) + "\n\n## Table Of Content\n\n")
+	for _, entry := range indexEntries(chapters) {
+		indent := strings.Repeat("   ", entry.Depth)
+		if entry.Folder != "" {
+			index.WriteString(fmt.Sprintf("%s- **%s/**\n", indent, escapeMarkdown(entry.Folder)))
+			continue
+		}
+		c := entry.Chapter
+		index.WriteString(fmt.Sprintf("%s%d. [%s](%s)\n", indent, c.Number, escapeMarkdown(c.Name), c.File))
+	}
+	_, err := io.WriteString(w, index.String())
+	return err
+}
+
+func (r markdownRenderer) draftBanner() string {
+	if !r.site.Draft {
+		return ""
+	}
+	return "> **DRAFT:** " + escapeMarkdown(draftNotice) + "\n\n"
+}
+
+// mdWriter collects the Markdown blocks of a document. Blocks are separated by blank lines.
+type mdWriter struct {
+	doc      *Document
+	blocks   []string
+	sections []*Section
+	// tocAt is the block index after the first title, where the table of contents is inserted.
+	tocAt int
+}
+
+func renderMarkdown(doc *Document) string {
+	w := &mdWriter{doc: doc, tocAt: -1}
+	w.nodes(doc.Children)
+
+	blocks := w.blocks
+	if len(w.sections) > 0 {
+		var toc strings.Builder
+		toc.WriteString("## Table of Contents\n\n")
+		for i, depth := range tocDepths(w.sections) {
+			if i > 0 {
+				toc.WriteString("\n")
+			}
+			section := w.sections[i]
+			toc.WriteString(fmt.Sprintf("%s- [%s](#%s)", strings.Repeat("  ", depth), escapeMarkdown(section.Heading()), section.ID))
+		}
+		at := w.tocAt
+		if at < 0 {
+			at = 0
+		}
+		blocks = append(blocks[:at:at], append([]string{toc.String()}, blocks[at:]...)...)
+	}
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+func (w *mdWriter) block(s string) {
+	w.blocks = append(w.blocks, s)
+}
+
+func (w *mdWriter) nodes(nodes []Node) {
+	for _, n := range nodes {
+		w.node(n)
+	}
+}
+
+func (w *mdWriter) node(n Node) {
+	switch n := n.(type) {
+	case *Metadata:
+		w.metadata(n)
+	case *Abstract:
+		w.block("## Abstract")
+	case *Section:
+		w.sections = append(w.sections, n)
+		// The anchor of the heading is set explicitly, the ones that Markdown viewers
+		// derive from the text differ from the section IDs that links point to.
+		w.block(strings.Repeat("#", n.Level+1) + " <a id=\"" + n.ID + "\"></a>" + escapeMarkdown(n.Heading()))
+		w.nodes(n.Children)
+	case *Admonition:
+		w.admonition(n)
+	case *CodeBlock:
+		w.codeBlock(n)
+	case *Table:
+		w.table(n.Rows)
+	case *Row:
+		w.table([]*Row{n})
+	case *List:
+		w.list(n)
+	case *ParamList:
+		w.paramList(n)
+	case *Reference:
+		href := strings.ReplaceAll(n.href(w.doc, markdownRenderer{}.Extension()), ")", "%29")
+		w.block("[" + escapeMarkdown(n.linkText()) + "](" + href + ")")
+	case *Deprecated:
+		w.block("**_Deprecated!_**")
+	case *Example:
+		title := "**Example:**"
+		if n.Kind == UseCaseBlock {
+			title = "**UseCase:**"
+		}
+		w.quote(title, n.Children)
+	case *Internal:
+		w.quote("**Internal:**", n.Children)
+	case *Paragraph:
+		lines := make([]string, len(n.Lines))
+		for i, l := range n.Lines {
+			lines[i] = renderInlineMarkdown(strings.TrimSpace(l))
+		}
+		w.block(strings.Join(lines, "\\\n"))
+	case *ToBeContinued:
+		w.block("*To be continued ...*")
+	case *BlankLine:
+		// Blocks are already separated by blank lines.
+	}
+}
+
+func (w *mdWriter) metadata(m *Metadata) {
+	value := escapeMarkdown(m.Value)
+	switch m.Kind {
+	case MetaTitle:
+		w.block("# " + value)
+		if w.tocAt < 0 {
+			w.tocAt = len(w.blocks)
+		}
+	case MetaAuthor:
+		w.block("Author: " + value)
+	case MetaDate:
+		w.block("Date: " + value)
+	case MetaVersion:
+		w.block("*Version:* " + value)
+	case MetaSince:
+		w.block("*Since:* " + value)
+	}
+}
+
+func (w *mdWriter) admonition(a *Admonition) {
+	text := renderInlineMarkdown(a.Text)
+	switch a.Kind {
+	case AdmonitionInfo:
+		w.block("> **Info:** " + text)
+	case AdmonitionWarning:
+		w.block("> **Warning:** " + text)
+	case AdmonitionTip:
+		w.block("> **Tip:** " + text)
+	case AdmonitionNote:
+		w.block("*Note:* " + text)
+	case AdmonitionTodo:
+		w.block("*TODO:* " + text)
+	}
+}
+
+func (w *mdWriter) codeBlock(c *CodeBlock) {
+	// The fence has to be longer than any backtick run inside the code.
+	longest := 0
+	for _, l := range c.Lines {
+		run := 0
+		for _, r := range l {
+			if r == '`' {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+
+	var code strings.Builder
+	code.WriteString(fence + "\n")
+	for _, l := range c.Lines {
+		code.WriteString(l + "\n")
+	}
+	code.WriteString(fence)
+	w.block(code.String())
+}
+
+// table renders rows as a pipe table. The first row becomes the header row.
+func (w *mdWriter) table(rows []*Row) {
+	if len(rows) == 0 {
+		return
+	}
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row.Cells))
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		cells := make([]string, columns)
+		for j := range cells {
+			if j < len(row.Cells) {
+				cells[j] = strings.ReplaceAll(renderInlineMarkdown(row.Cells[j]), "|", "\\|")
+			}
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	w.block(strings.Join(lines, "\n"))
+}
+
+func (w *mdWriter) list(l *List) {
+	if len(l.Items) == 0 {
+		return
+	}
+	lines := make([]string, len(l.Items))
+	for i, item := range l.Items {
+		marker := "-"
+		if l.Ordered {
+			marker = fmt.Sprintf("%d.", i+1)
+		}
+		lines[i] = marker + " " + renderInlineMarkdown(item.Text)
+	}
+	w.block(strings.Join(lines, "\n"))
+}
+
+func (w *mdWriter) paramList(p *ParamList) {
+	lines := []string{"**Parameters**"}
+	if p.Kind == ParamReturn {
+		lines[0] = "**Return:**"
+	}
+	lines = append(lines, "")
+	for _, value := range p.Values {
+		lines = append(lines, "- "+escapeMarkdown(value))
+	}
+	w.block(strings.Join(lines, "\n"))
+}
+
+// quote renders the children of an @example, @usecase or @internal block inside a blockquote.
+func (w *mdWriter) quote(title string, nodes []Node) {
+	inner := &mdWriter{doc: w.doc, tocAt: -1}
+	inner.nodes(nodes)
+
+	content := strings.Join(append([]string{title}, inner.blocks...), "\n\n")
+
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		if l == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + l
+		}
+	}
+	w.block(strings.Join(lines, "\n"))
+}
+
+// renderInlineMarkdown converts the inline markup of text to Markdown and escapes everything else.
+func renderInlineMarkdown(text string) string {
+	var b strings.Builder
+	spans := parseInline(text)
+	for i := 0; i < len(spans); {
+		url := spans[i].URL
+		end := i
+		for end < len(spans) && spans[end].URL == url {
+			end++
+		}
+		if url != "" {
+			b.WriteString("[")
+		}
+		for _, span := range spans[i:end] {
+			var s string
+			if span.Code {
+				s = markdownCodeSpan(span.Text)
+			} else {
+				s = escapeMarkdown(span.Text)
+			}
+			if span.Italic {
+				s = "*" + s + "*"
+			}
+			if span.Bold {
+				s = "**" + s + "**"
+			}
+			b.WriteString(s)
+		}
+		if url != "" {
+			b.WriteString("](" + strings.ReplaceAll(url, ")", "%29") + ")")
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// markdownCodeSpan wraps code in enough backticks to keep the backticks inside it.
+func markdownCodeSpan(code string) string {
+	fence := "`"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// escapeMarkdown escapes characters that Markdown would otherwise interpret as markup.
+func escapeMarkdown(input string) string {
+	var escaped strings.Builder
+	for i, r := range input {
+		switch r {
+		case '\\', '`', '*', '_', '[', ']', '<', '>', '#':
+			escaped.WriteByte('\\')
+		case '-', '+':
+			if i == 0 {
+				escaped.WriteByte('\\')
+			}
+		case '.', ')':
+			if isDigits(input[:i]) {
+				escaped.WriteByte('\\')
+			}
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
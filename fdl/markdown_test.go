@@ -0,0 +1,120 @@
+package fdl
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	input := "@title Guide\n" +
+		"@author Jane\n" +
+		"@section Getting Started\n" +
+		"First line with *stars* and 2*3\n" +
+		"second line\n" +
+		"\n" +
+		"@info Read this\n" +
+		"@warning Careful\n" +
+		"@tip Use it\n" +
+		"@code\n" +
+		"fmt.Println(\"```\")\n" +
+		"@endcode\n" +
+		"@table\n" +
+		"@row Name | Value\n" +
+		"@row a|b\n" +
+		"@endtable\n" +
+		"@list -n\n" +
+		"@item one\n" +
+		"@item two\n" +
+		"@endlist\n" +
+		"@example\n" +
+		"@note inside\n" +
+		"@endexample\n"
+
+	expected := "# Guide\n" +
+		"\n" +
+		"## Table of Contents\n" +
+		"\n" +
+		"- [Getting Started](#getting-started)\n" +
+		"\n" +
+		"Author: Jane\n" +
+		"\n" +
+		"## <a id=\"getting-started\"></a>Getting Started\n" +
+		"\n" +
+		"First line with **stars** and 2\\*3\\\n" +
+		"second line\n" +
+		"\n" +
+		"> **Info:** Read this\n" +
+		"\n" +
+		"> **Warning:** Careful\n" +
+		"\n" +
+		"> **Tip:** Use it\n" +
+		"\n" +
+		"````\n" +
+		"fmt.Println(\"```\")\n" +
+		"````\n" +
+		"\n" +
+		"| Name | Value |\n" +
+		"| --- | --- |\n" +
+		"| a | b |\n" +
+		"\n" +
+		"1. one\n" +
+		"2. two\n" +
+		"\n" +
+		"> **Example:**\n" +
+		">\n" +
+		"> *Note:* inside\n"
+
+	result := renderMarkdown(mustParse(t, input))
+	if result != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := map[string]string{
+		"plain text":    "plain text",
+		"# not heading": "\\# not heading",
+		"- not a list":  "\\- not a list",
+		"1. not a list": "1\\. not a list",
+		"a_b*c`d":       "a\\_b\\*c\\`d",
+	}
+	for input, expected := range tests {
+		if result := escapeMarkdown(input); result != expected {
+			t.Errorf("escapeMarkdown(%q) = %q, want %q", input, result, expected)
+		}
+	}
+}
+
+func TestMarkdownRendererIndex(t *testing.T) {
+	var out bytes.Buffer
+	chapters := []Chapter{{Number: 1, Name: "intro", File: "intro.md"}, {Number: 2, Name: "setup", File: "setup.md"}}
+	if err := (markdownRenderer{}).RenderIndex(&out, chapters); err != nil {
+		t.Fatalf("RenderIndex failed: %v", err)
+	}
+
+	expected := "# Documentation\n\n## Table Of Content\n\n1. [intro](intro.md)\n2. [setup](setup.md)\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestMarkdownAnchorsMatchLinks(t *testing.T) {
+	doc := mustParse(t, "@title Guide\n@section What's new?\n@subsection Setup\n@section Setup\n@ref #setup-1\n")
+	numberSections(doc)
+	result := renderMarkdown(doc)
+
+	anchors := make(map[string]bool)
+	for _, match := range regexp.MustCompile(`(?m)^#+ <a id="([^"]+)"></a>`).FindAllStringSubmatch(result, -1) {
+		anchors[match[1]] = true
+	}
+	links := regexp.MustCompile(`\]\(#([^)]+)\)`).FindAllStringSubmatch(result, -1)
+	if len(links) != 4 {
+		t.Fatalf("Expected 3 links in the table of contents and one @ref, got:\n%s", result)
+	}
+	for _, link := range links {
+		if !anchors[link[1]] {
+			t.Errorf("Expected an anchor for the link to #%s, got:\n%s", link[1], result)
+		}
+	}
+}
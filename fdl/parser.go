@@ -0,0 +1,345 @@
+package fdl
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// parser turns the lines of an .fdl file into a Document tree. Block directives
+// (@section, @example, @usecase, @internal) open containers on the stack, while @code, @table
+// and @list are tracked separately because they only accept their own content.
+type parser struct {
+	diags  *Diagnostics
+	doc    *Document
+	stack  []Container
+	code   *CodeBlock
+	table  *Table
+	list   *List
+	para   *Paragraph
+	values conditionValues
+	ifs    []*conditional
+	// sections hands out the section IDs, which are unique within the document.
+	sections sectionRegistry
+	// including are the files that are being parsed, the document first and then the
+	// files it includes, to detect @include cycles.
+	including []string
+}
+
+// parse reads an .fdl document from r. path is only used for source positions.
+// Malformed markup is reported to diags, only read errors are returned.
+// @if conditions are evaluated without any variables or tags.
+func parse(r io.Reader, path string, diags *Diagnostics) (*Document, error) {
+	return parseFor(r, path, conditionValues{}, diags)
+}
+
+// parseFor is like parse, but keeps the @if branches selected by values.
+func parseFor(r io.Reader, path string, values conditionValues, diags *Diagnostics) (*Document, error) {
+	p := &parser{diags: diags, values: values}
+	p.doc = &Document{Pos: Pos{File: path, Line: 1, Column: 1}, Path: path}
+	p.stack = []Container{p.doc}
+	p.including = []string{filepath.ToSlash(filepath.Clean(path))}
+
+	if err := p.parseLines(r, path); err != nil {
+		return nil, err
+	}
+	p.closeBlocks()
+	for _, c := range p.ifs {
+		p.diags.Errorf(c.Pos, CodeUnterminatedBlock, "@if is never closed with @endif")
+	}
+	for i := len(p.stack) - 1; i > 0; i-- {
+		switch block := p.stack[i].(type) {
+		case *Example:
+			p.diags.Errorf(block.Pos, CodeUnterminatedBlock, "%s is never closed with %s", exampleDirectives[block.Kind][0], exampleDirectives[block.Kind][1])
+		case *Internal:
+			p.diags.Errorf(block.Pos, CodeUnterminatedBlock, "@internal is never closed with @endinternal")
+		}
+	}
+	return p.doc, nil
+}
+
+// splitDirective splits "@name argument" into its name and trimmed argument.
+// ok is false if the line is not a directive.
+func splitDirective(line string) (name string, arg string, ok bool) {
+	if !strings.HasPrefix(line, "@") {
+		return "", "", false
+	}
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, "", true
+	}
+	return line[:i], strings.TrimSpace(line[i+1:]), true
+}
+
+// parseLines parses all lines of r, which is the content of file.
+func (p *parser) parseLines(r io.Reader, file string) error {
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		p.parseLine(strings.TrimSuffix(scanner.Text(), "\r"), Pos{File: file, Line: lineNumber, Column: 1})
+	}
+	return scanner.Err()
+}
+
+func (p *parser) current() Container {
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *parser) add(n Node) {
+	p.para = nil
+	p.current().appendChild(n)
+}
+
+// inBlock reports whether an @example, @usecase or @internal block is open.
+func (p *parser) inBlock() bool {
+	switch p.current().(type) {
+	case *Example, *Internal:
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseLine(line string, pos Pos) {
+	if p.code != nil {
+		if name, _, ok := splitDirective(line); ok && name == "@endcode" {
+			p.code = nil
+			return
+		}
+		p.code.Lines = append(p.code.Lines, line)
+		return
+	}
+
+	if name, arg, ok := splitDirective(line); ok && p.parseConditional(name, arg, pos) {
+		return
+	}
+	if p.skipping() {
+		// A code block of a left out branch is read like any other, so directives
+		// inside it, e.g. an @if shown as an example, don't end the branch.
+		if name, _, ok := splitDirective(line); ok && name == "@code" {
+			p.code = &CodeBlock{Pos: pos}
+		}
+		return
+	}
+	if p.list != nil && !continuesList(line) {
+		// The list can't hold other content, which would otherwise be rendered after
+		// all of its items.
+		p.diags.Warnf(pos, CodeContentInList, "content inside @list ends the list opened at line %d", p.list.Line)
+		p.list = nil
+	}
+	if name, arg, ok := splitDirective(line); ok && p.parseDirective(name, arg, pos) {
+		p.para = nil
+		return
+	}
+	p.parseText(line, pos)
+}
+
+// continuesList reports whether line may appear inside an open @list: a blank line,
+// an @item, the closing @endlist, a new @list or an @include of further items.
+func continuesList(line string) bool {
+	if strings.TrimSpace(line) == "" {
+		return true
+	}
+	name, _, ok := splitDirective(line)
+	return ok && (name == "@item" || name == "@endlist" || name == "@list" || name == "@include")
+}
+
+// parseDirective handles a known directive and reports whether it did so.
+// Unknown or misplaced directives are left to parseText.
+func (p *parser) parseDirective(name string, arg string, pos Pos) bool {
+	switch name {
+	case "@include":
+		p.parseInclude(arg, pos)
+	case "@title":
+		p.add(&Metadata{Pos: pos, Kind: MetaTitle, Value: arg})
+	case "@author":
+		p.add(&Metadata{Pos: pos, Kind: MetaAuthor, Value: arg})
+	case "@date":
+		p.add(&Metadata{Pos: pos, Kind: MetaDate, Value: arg})
+	case "@version":
+		p.add(&Metadata{Pos: pos, Kind: MetaVersion, Value: arg})
+	case "@since":
+		p.add(&Metadata{Pos: pos, Kind: MetaSince, Value: arg})
+	case "@abstract":
+		p.add(&Abstract{Pos: pos})
+	case "@info":
+		p.add(&Admonition{Pos: pos, Kind: AdmonitionInfo, Text: arg})
+	case "@warning":
+		p.add(&Admonition{Pos: pos, Kind: AdmonitionWarning, Text: arg})
+	case "@tip":
+		p.add(&Admonition{Pos: pos, Kind: AdmonitionTip, Text: arg})
+	case "@note":
+		p.add(&Admonition{Pos: pos, Kind: AdmonitionNote, Text: arg})
+	case "@todo":
+		p.add(&Admonition{Pos: pos, Kind: AdmonitionTodo, Text: arg})
+	case "@section", "@subsection", "@subsubsection":
+		if p.inBlock() {
+			return false
+		}
+		p.openSection(sectionLevels[name], arg, pos)
+	case "@code":
+		p.code = &CodeBlock{Pos: pos}
+		p.add(p.code)
+	case "@tbc":
+		p.add(&ToBeContinued{Pos: pos})
+	case "@table":
+		p.closeTable()
+		p.table = &Table{Pos: pos}
+		p.add(p.table)
+	case "@row":
+		row := &Row{Pos: pos, Cells: splitCells(arg)}
+		if p.table == nil {
+			p.diags.Errorf(pos, CodeRowOutsideTable, "@row outside of @table")
+			p.add(row)
+			break
+		}
+		p.table.Rows = append(p.table.Rows, row)
+	case "@endtable":
+		if p.table == nil {
+			p.diags.Warnf(pos, CodeStrayEndTable, "@endtable without matching @table")
+		}
+		p.table = nil
+	case "@ref", "@link":
+		target, text, _ := strings.Cut(arg, " ")
+		p.add(&Reference{Pos: pos, Target: target, Text: strings.TrimSpace(text)})
+	case "@deprecated":
+		p.add(&Deprecated{Pos: pos})
+	case "@param":
+		p.add(&ParamList{Pos: pos, Kind: ParamInput, Values: splitCells(arg)})
+	case "@return":
+		p.add(&ParamList{Pos: pos, Kind: ParamReturn, Values: splitCells(arg)})
+	case "@list":
+		p.closeList()
+		p.list = &List{Pos: pos, Ordered: strings.HasPrefix(arg, "-n")}
+		p.add(p.list)
+	case "@item":
+		if p.list == nil {
+			p.diags.Warnf(pos, CodeItemOutsideList, "@item outside of @list is shown as plain text")
+			return false
+		}
+		p.list.Items = append(p.list.Items, &Item{Pos: pos, Text: arg})
+	case "@endlist":
+		if p.list == nil {
+			p.diags.Warnf(pos, CodeStrayEndList, "@endlist without matching @list")
+		}
+		p.list = nil
+	case "@example":
+		p.openExample(ExampleBlock, pos)
+	case "@usecase":
+		p.openExample(UseCaseBlock, pos)
+	case "@endexample", "@endusecase":
+		p.closeExample(name, pos)
+	case "@internal":
+		internal := &Internal{Pos: pos}
+		p.add(internal)
+		p.stack = append(p.stack, internal)
+	case "@endinternal":
+		if _, ok := p.current().(*Internal); !ok {
+			p.diags.Warnf(pos, CodeStrayEndInternal, "@endinternal without matching @internal")
+			break
+		}
+		p.stack = p.stack[:len(p.stack)-1]
+	case "@endcode":
+		p.diags.Warnf(pos, CodeStrayEndCode, "@endcode without matching @code")
+	default:
+		p.diags.Warnf(pos, CodeUnknownDirective, "unknown directive %s is shown as plain text", name)
+		return false
+	}
+	return true
+}
+
+// sectionLevels maps the heading directives to their Section.Level.
+var sectionLevels = map[string]int{"@section": 1, "@subsection": 2, "@subsubsection": 3}
+
+// openSection starts a section of the given level. It becomes a child of the closest
+// open section of a lower level, or of the document.
+func (p *parser) openSection(level int, title string, pos Pos) {
+	p.closeBlocks()
+	for len(p.stack) > 1 {
+		if parent, ok := p.current().(*Section); ok && parent.Level < level {
+			break
+		}
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+	section := &Section{Pos: pos, Level: level, Title: title, ID: p.sections.register(title)}
+	p.add(section)
+	p.stack = append(p.stack, section)
+}
+
+// exampleDirectives holds the opening and closing directive of each example kind.
+var exampleDirectives = map[ExampleKind][2]string{
+	ExampleBlock: {"@example", "@endexample"},
+	UseCaseBlock: {"@usecase", "@endusecase"},
+}
+
+func (p *parser) closeExample(name string, pos Pos) {
+	example, ok := p.current().(*Example)
+	if !ok {
+		p.diags.Warnf(pos, CodeStrayEndExample, "%s without matching %s", name, strings.Replace(name, "@end", "@", 1))
+		return
+	}
+	if expected := exampleDirectives[example.Kind][1]; name != expected {
+		p.diags.Warnf(pos, CodeMismatchedEnd, "%s closes %s opened at line %d, expected %s",
+			name, exampleDirectives[example.Kind][0], example.Line, expected)
+	}
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
+// closeBlocks ends open @code, @table and @list blocks, reporting them as unterminated.
+func (p *parser) closeBlocks() {
+	if p.code != nil {
+		p.diags.Errorf(p.code.Pos, CodeUnterminatedCode, "@code is never closed with @endcode")
+		p.code = nil
+	}
+	p.closeTable()
+	p.closeList()
+}
+
+func (p *parser) closeTable() {
+	if p.table != nil {
+		p.diags.Errorf(p.table.Pos, CodeUnterminatedTable, "@table is never closed with @endtable")
+		p.table = nil
+	}
+}
+
+func (p *parser) closeList() {
+	if p.list != nil {
+		p.diags.Errorf(p.list.Pos, CodeUnterminatedList, "@list is never closed with @endlist")
+		p.list = nil
+	}
+}
+
+func (p *parser) openExample(kind ExampleKind, pos Pos) {
+	example := &Example{Pos: pos, Kind: kind}
+	p.add(example)
+	p.stack = append(p.stack, example)
+}
+
+func (p *parser) parseText(line string, pos Pos) {
+	if p.table != nil {
+		return
+	}
+	if strings.TrimSpace(line) == "" {
+		if p.list != nil {
+			return
+		}
+		p.add(&BlankLine{Pos: pos})
+		return
+	}
+	if p.para != nil {
+		p.para.Lines = append(p.para.Lines, line)
+		return
+	}
+	para := &Paragraph{Pos: pos, Lines: []string{line}}
+	p.add(para)
+	p.para = para
+}
+
+func splitCells(arg string) []string {
+	cells := strings.Split(arg, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
@@ -0,0 +1,112 @@
+package fdl
+
+import "testing"
+
+func TestParseDocumentTree(t *testing.T) {
+	input := "@title Manual\n" +
+		"Intro line\n" +
+		"@section Setup\n" +
+		"first line\n" +
+		"second line\n" +
+		"\n" +
+		"@code\n" +
+		"@info stays code\n" +
+		"@endcode\n" +
+		"@example\n" +
+		"@section not a heading\n" +
+		"@endexample\n" +
+		"@section Usage\n" +
+		"@list -n\n" +
+		"@item one\n" +
+		"@item two\n" +
+		"@endlist\n"
+
+	doc := mustParse(t, input)
+
+	if len(doc.Children) != 4 {
+		t.Fatalf("Expected 4 top-level nodes, got %d: %#v", len(doc.Children), doc.Children)
+	}
+	title, ok := doc.Children[0].(*Metadata)
+	if !ok || title.Kind != MetaTitle || title.Value != "Manual" {
+		t.Errorf("Expected title metadata, got %#v", doc.Children[0])
+	}
+
+	setup, ok := doc.Children[2].(*Section)
+	if !ok {
+		t.Fatalf("Expected section, got %#v", doc.Children[2])
+	}
+	if setup.Title != "Setup" || setup.ID != "setup" || setup.Position() != (Pos{File: "test.fdl", Line: 3, Column: 1}) {
+		t.Errorf("Unexpected section %#v", setup)
+	}
+	if len(setup.Children) != 4 {
+		t.Fatalf("Expected 4 nodes in section, got %d: %#v", len(setup.Children), setup.Children)
+	}
+	if para, ok := setup.Children[0].(*Paragraph); !ok || len(para.Lines) != 2 {
+		t.Errorf("Expected paragraph with two lines, got %#v", setup.Children[0])
+	}
+	if _, ok := setup.Children[1].(*BlankLine); !ok {
+		t.Errorf("Expected blank line, got %#v", setup.Children[1])
+	}
+	if code, ok := setup.Children[2].(*CodeBlock); !ok || len(code.Lines) != 1 || code.Lines[0] != "@info stays code" {
+		t.Errorf("Expected verbatim code block, got %#v", setup.Children[2])
+	}
+	example, ok := setup.Children[3].(*Example)
+	if !ok || len(example.Children) != 1 {
+		t.Fatalf("Expected example with one child, got %#v", setup.Children[3])
+	}
+	if _, ok := example.Children[0].(*Paragraph); !ok {
+		t.Errorf("Expected @section inside @example to stay text, got %#v", example.Children[0])
+	}
+
+	usage := doc.Children[3].(*Section)
+	list, ok := usage.Children[0].(*List)
+	if !ok || !list.Ordered || len(list.Items) != 2 || list.Items[1].Line != 16 {
+		t.Errorf("Unexpected list %#v", usage.Children[0])
+	}
+}
+
+func TestSplitDirective(t *testing.T) {
+	tests := []struct {
+		line string
+		name string
+		arg  string
+		ok   bool
+	}{
+		{"@title  My Title ", "@title", "My Title", true},
+		{"@endcode", "@endcode", "", true},
+		{"@row\ta|b", "@row", "a|b", true},
+		{"plain text", "", "", false},
+	}
+
+	for _, tt := range tests {
+		name, arg, ok := splitDirective(tt.line)
+		if name != tt.name || arg != tt.arg || ok != tt.ok {
+			t.Errorf("splitDirective(%q) = %q, %q, %v; want %q, %q, %v", tt.line, name, arg, ok, tt.name, tt.arg, tt.ok)
+		}
+	}
+}
+
+func TestWalkVisitsNodesInOrder(t *testing.T) {
+	doc := mustParse(t, "@section A\n@table\n@row x\n@endtable\n@section B\n")
+
+	var visited []string
+	Walk(doc, func(n Node) bool {
+		switch n := n.(type) {
+		case *Section:
+			visited = append(visited, n.Title)
+		case *Row:
+			visited = append(visited, n.Cells[0])
+		}
+		return true
+	})
+
+	expected := []string{"A", "x", "B"}
+	if len(visited) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, visited)
+	}
+	for i := range expected {
+		if visited[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, visited)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package fdl
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A4 in points and the page margins used for all generated pages.
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 56.7
+	pdfTextSize   = 10.5
+	pdfLeading    = 1.4
+)
+
+var (
+	colorInfo        = pdfColor{0.906, 0.953, 0.996}
+	colorInfoBar     = pdfColor{0.129, 0.588, 0.953}
+	colorWarning     = pdfColor{1, 0.8, 0.796}
+	colorWarningBar  = pdfColor{0.957, 0.263, 0.212}
+	colorTip         = pdfColor{0.561, 0.737, 0.561}
+	colorTipBar      = pdfColor{0.431, 0.545, 0.239}
+	colorCode        = pdfColor{0.949, 0.949, 0.949}
+	colorExampleText = pdfColor{0.3, 0.3, 0.3}
+	colorDraft       = pdfColor{1, 0.953, 0.804}
+	colorDraftBar    = pdfColor{1, 0.596, 0}
+)
+
+// pdfRenderer lays out documents on paginated A4 pages, starting with a cover page
+// that shows the @title, @author and @date of the document.
+type pdfRenderer struct {
+	site Settings
+}
+
+func (pdfRenderer) Extension() string {
+	return ".pdf"
+}
+
+func (r pdfRenderer) RenderDocument(w io.Writer, doc *Document) error {
+	l := &pdfLayout{draft: r.site.Draft}
+	l.document(doc)
+	return writePDF(w, documentTitle(doc), l.numberedPages())
+}
+
+// RenderBundle writes all documents into a single PDF, in the order of the index.
+func (r pdfRenderer) RenderBundle(w io.Writer, docs []*Document) error {
+	l := &pdfLayout{draft: r.site.Draft}
+	for _, doc := range docs {
+		l.document(doc)
+	}
+	return writePDF(w, r.site.title(), l.numberedPages())
+}
+
+func (r pdfRenderer) RenderIndex(w io.Writer, chapters []Chapter) error {
+	l := &pdfLayout{draft: r.site.Draft}
+	l.newPage(true)
+	l.draftBanner()
+	l.heading(r.site.title(), 22)
+	l.heading("Table Of Content", 16)
+	for _, entry := range indexEntries(chapters) {
+		style := pdfLayoutStyle{indent: 16 * float64(entry.Depth)}
+		if entry.Folder != "" {
+			l.paragraph([]pdfRun{{fontBold, entry.Folder + "/"}}, style)
+			continue
+		}
+		l.paragraph([]pdfRun{{fontRegular, strconv.Itoa(entry.Chapter.Number) + " " + entry.Chapter.Name}}, style)
+	}
+	return writePDF(w, r.site.title(), l.numberedPages())
+}
+
+// documentTitle returns the value of the first @title, or the file name if there is none.
+func documentTitle(doc *Document) string {
+	if title := documentMetadata(doc, MetaTitle); title != "" {
+		return title
+	}
+	return doc.Path
+}
+
+// documentMetadata returns the value of the first metadata node of the given kind.
+func documentMetadata(doc *Document, kind MetaKind) string {
+	value := ""
+	Walk(doc, func(n Node) bool {
+		if m, ok := n.(*Metadata); ok && m.Kind == kind && value == "" {
+			value = m.Value
+		}
+		return value == ""
+	})
+	return value
+}
+
+// pdfRun is a piece of text set in a single font.
+type pdfRun struct {
+	font pdfFont
+	text string
+}
+
+// pdfLayoutStyle describes how a block of lines is drawn.
+type pdfLayoutStyle struct {
+	size       float64
+	color      pdfColor
+	background *pdfColor
+	bar        *pdfColor
+	indent     float64
+}
+
+// pdfLayout places blocks from top to bottom and starts a new page when the current one is full.
+type pdfLayout struct {
+	pages  []*pdfPage
+	page   *pdfPage
+	y      float64
+	indent float64
+	// draft marks the cover and index pages as development documentation.
+	draft bool
+	// doc is the document that is laid out, used for the targets of references.
+	doc *Document
+}
+
+func (l *pdfLayout) newPage(numbered bool) {
+	l.page = &pdfPage{numbered: numbered}
+	l.pages = append(l.pages, l.page)
+	l.y = pdfPageHeight - pdfMargin
+}
+
+// ensure starts a new page unless height points are left on the current one.
+func (l *pdfLayout) ensure(height float64) {
+	if l.page == nil || l.y-height < pdfMargin {
+		l.newPage(true)
+	}
+}
+
+func (l *pdfLayout) space(height float64) {
+	if l.page != nil && l.y-height >= pdfMargin {
+		l.y -= height
+	}
+}
+
+func (l *pdfLayout) width() float64 {
+	return pdfPageWidth - 2*pdfMargin - l.indent
+}
+
+// numberedPages adds the page number footer and returns all pages.
+func (l *pdfLayout) numberedPages() []*pdfPage {
+	number := 0
+	for _, page := range l.pages {
+		if !page.numbered {
+			continue
+		}
+		number++
+		label := strconv.Itoa(number)
+		x := (pdfPageWidth - fontRegular.width(label, 9)) / 2
+		page.text(x, pdfMargin/2, fontRegular, 9, colorBlack, label)
+	}
+	return l.pages
+}
+
+func (l *pdfLayout) document(doc *Document) {
+	l.doc = doc
+	l.cover(doc)
+	l.newPage(true)
+	l.nodes(doc.Children)
+}
+
+func (l *pdfLayout) cover(doc *Document) {
+	l.newPage(false)
+	l.draftBanner()
+	l.y = pdfPageHeight * 0.62
+	l.paragraph([]pdfRun{{fontBold, documentTitle(doc)}}, pdfLayoutStyle{size: 28})
+	l.space(18)
+	for _, kind := range []MetaKind{MetaAuthor, MetaDate} {
+		if value := documentMetadata(doc, kind); value != "" {
+			l.paragraph([]pdfRun{{fontRegular, value}}, pdfLayoutStyle{size: 14})
+		}
+	}
+}
+
+func (l *pdfLayout) draftBanner() {
+	if l.draft {
+		l.box("DRAFT ", []pdfRun{{fontRegular, draftNotice}}, colorDraft, colorDraftBar)
+	}
+}
+
+func (l *pdfLayout) nodes(nodes []Node) {
+	for _, n := range nodes {
+		l.node(n)
+	}
+}
+
+func (l *pdfLayout) node(n Node) {
+	switch n := n.(type) {
+	case *Metadata:
+		l.metadata(n)
+	case *Abstract:
+		l.heading("Abstract", 16)
+	case *Section:
+		l.heading(n.Heading(), 18-2*float64(n.Level))
+		l.nodes(n.Children)
+	case *Admonition:
+		l.admonition(n)
+	case *CodeBlock:
+		l.codeBlock(n)
+	case *Table:
+		l.table(n.Rows)
+	case *Row:
+		l.table([]*Row{n})
+	case *List:
+		l.list(n)
+	case *ParamList:
+		label := "Parameters"
+		if n.Kind == ParamReturn {
+			label = "Return:"
+		}
+		l.paragraph([]pdfRun{{fontBold, label}}, pdfLayoutStyle{})
+		for _, value := range n.Values {
+			l.paragraph([]pdfRun{{fontRegular, value}}, pdfLayoutStyle{indent: 14})
+		}
+	case *Reference:
+		l.paragraph([]pdfRun{{fontRegular, n.linkText()}, {fontRegular, " (" + n.href(l.doc, pdfRenderer{}.Extension()) + ")"}}, pdfLayoutStyle{})
+	case *Deprecated:
+		l.paragraph([]pdfRun{{fontBold, "Deprecated!"}}, pdfLayoutStyle{color: colorRed})
+	case *Example:
+		title := "Example:"
+		if n.Kind == UseCaseBlock {
+			title = "UseCase:"
+		}
+		l.titledBlock(title, n.Children)
+	case *Internal:
+		l.titledBlock("Internal:", n.Children)
+	case *Paragraph:
+		for _, line := range n.Lines {
+			l.paragraph(inlineRuns(strings.TrimSpace(line)), pdfLayoutStyle{})
+		}
+	case *BlankLine:
+		l.space(pdfTextSize * 0.7)
+	case *ToBeContinued:
+		l.paragraph([]pdfRun{{fontItalic, "To be continued ..."}}, pdfLayoutStyle{})
+	}
+}
+
+func (l *pdfLayout) metadata(m *Metadata) {
+	switch m.Kind {
+	case MetaTitle:
+		l.heading(m.Value, 22)
+	case MetaVersion:
+		l.paragraph([]pdfRun{{fontItalic, "Version: "}, {fontRegular, m.Value}}, pdfLayoutStyle{})
+	case MetaSince:
+		l.paragraph([]pdfRun{{fontItalic, "Since: "}, {fontRegular, m.Value}}, pdfLayoutStyle{})
+	}
+	// @author and @date are shown on the cover page.
+}
+
+func (l *pdfLayout) heading(text string, size float64) {
+	l.space(size * 0.6)
+	l.ensure(size * pdfLeading * 3)
+	l.paragraph([]pdfRun{{fontBold, text}}, pdfLayoutStyle{size: size})
+	l.space(size * 0.3)
+}
+
+func (l *pdfLayout) admonition(a *Admonition) {
+	switch a.Kind {
+	case AdmonitionInfo:
+		l.box("Info: ", inlineRuns(a.Text), colorInfo, colorInfoBar)
+	case AdmonitionWarning:
+		l.box("Warning: ", inlineRuns(a.Text), colorWarning, colorWarningBar)
+	case AdmonitionTip:
+		l.box("Tip: ", inlineRuns(a.Text), colorTip, colorTipBar)
+	case AdmonitionNote:
+		l.paragraph(append([]pdfRun{{fontItalic, "Note: "}}, inlineRuns(a.Text)...), pdfLayoutStyle{})
+	case AdmonitionTodo:
+		l.paragraph(append([]pdfRun{{fontItalic, "TODO: "}}, inlineRuns(a.Text)...), pdfLayoutStyle{})
+	}
+}
+
+func (l *pdfLayout) box(label string, runs []pdfRun, background pdfColor, bar pdfColor) {
+	l.space(4)
+	style := pdfLayoutStyle{background: &background, bar: &bar, indent: 12}
+	l.paragraph(append([]pdfRun{{fontBold, label}}, runs...), style)
+	l.space(6)
+}
+
+func (l *pdfLayout) codeBlock(c *CodeBlock) {
+	l.space(4)
+	background := colorCode
+	style := pdfLayoutStyle{size: 9, background: &background, indent: 8}
+	for _, line := range c.Lines {
+		// Code keeps its indentation, so long lines are broken by characters, not words.
+		chars := max(1, int((l.width()-2*style.indent)/fontMono.width(" ", style.size)))
+		runes := []rune(strings.ReplaceAll(line, "\t", "    "))
+		for len(runes) > chars {
+			l.line([]pdfRun{{fontMono, string(runes[:chars])}}, style)
+			runes = runes[chars:]
+		}
+		l.line([]pdfRun{{fontMono, string(runes)}}, style)
+	}
+	l.space(6)
+}
+
+func (l *pdfLayout) list(list *List) {
+	for i, item := range list.Items {
+		marker := "•"
+		if list.Ordered {
+			marker = strconv.Itoa(i+1) + "."
+		}
+		l.ensure(pdfTextSize * pdfLeading)
+		l.page.text(pdfMargin+l.indent+4, l.y-pdfTextSize, fontRegular, pdfTextSize, colorBlack, marker)
+		l.paragraph(inlineRuns(item.Text), pdfLayoutStyle{indent: 20})
+	}
+}
+
+// titledBlock draws the children of an @example, @usecase or @internal block indented below their title.
+func (l *pdfLayout) titledBlock(title string, nodes []Node) {
+	l.space(4)
+	l.paragraph([]pdfRun{{fontBold, title}}, pdfLayoutStyle{color: colorExampleText})
+	l.indent += 14
+	l.nodes(nodes)
+	l.indent -= 14
+	l.space(6)
+}
+
+// table draws rows as a grid with equally wide columns.
+func (l *pdfLayout) table(rows []*Row) {
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row.Cells))
+	}
+	if columns == 0 {
+		return
+	}
+	const padding = 4
+	cellWidth := l.width() / float64(columns)
+	lineHeight := pdfTextSize * pdfLeading
+
+	l.space(4)
+	for _, row := range rows {
+		cells := make([][][]pdfRun, columns)
+		lines := 1
+		for i := range cells {
+			if i < len(row.Cells) {
+				cells[i] = wrapRuns(inlineRuns(row.Cells[i]), pdfTextSize, cellWidth-2*padding)
+			}
+			lines = max(lines, len(cells[i]))
+		}
+		height := float64(lines)*lineHeight + 2*padding
+		l.ensure(height)
+		for i, cellLines := range cells {
+			x := pdfMargin + l.indent + float64(i)*cellWidth
+			l.page.strokeRect(x, l.y-height, cellWidth, height)
+			for j, line := range cellLines {
+				l.drawRuns(x+padding, l.y-padding-float64(j)*lineHeight-pdfTextSize, line, pdfTextSize, colorBlack)
+			}
+		}
+		l.y -= height
+	}
+	l.space(6)
+}
+
+// paragraph wraps runs to the available width and draws the resulting lines.
+func (l *pdfLayout) paragraph(runs []pdfRun, style pdfLayoutStyle) {
+	if style.size == 0 {
+		style.size = pdfTextSize
+	}
+	width := l.width() - style.indent
+	if style.background != nil {
+		width -= style.indent
+	}
+	for _, line := range wrapRuns(runs, style.size, width) {
+		l.line(line, style)
+	}
+}
+
+// line draws a single line of runs, including its background and side bar.
+func (l *pdfLayout) line(runs []pdfRun, style pdfLayoutStyle) {
+	if style.size == 0 {
+		style.size = pdfTextSize
+	}
+	height := style.size * pdfLeading
+	l.ensure(height)
+	x := pdfMargin + l.indent
+	if style.background != nil {
+		l.page.fillRect(x, l.y-height, l.width(), height, *style.background)
+	}
+	if style.bar != nil {
+		l.page.fillRect(x, l.y-height, 5, height, *style.bar)
+	}
+	l.drawRuns(x+style.indent, l.y-style.size, runs, style.size, style.color)
+	l.y -= height
+}
+
+func (l *pdfLayout) drawRuns(x, y float64, runs []pdfRun, size float64, color pdfColor) {
+	for _, run := range runs {
+		l.page.text(x, y, run.font, size, color, run.text)
+		x += run.font.width(run.text, size)
+	}
+}
+
+// inlineRuns converts the inline markup of text to runs. Printed pages can't be
+// clicked, so links are followed by their URL.
+func inlineRuns(text string) []pdfRun {
+	var runs []pdfRun
+	spans := parseInline(text)
+	for i, span := range spans {
+		font := fontRegular
+		switch {
+		case span.Code:
+			font = fontMono
+		case span.Bold && span.Italic:
+			font = fontBoldItalic
+		case span.Bold:
+			font = fontBold
+		case span.Italic:
+			font = fontItalic
+		}
+		runs = append(runs, pdfRun{font, span.Text})
+
+		lastOfLink := span.URL != "" && (i+1 == len(spans) || spans[i+1].URL != span.URL)
+		if lastOfLink && span.Text != span.URL {
+			runs = append(runs, pdfRun{fontRegular, " (" + span.URL + ")"})
+		}
+	}
+	return runs
+}
+
+// wrapRuns breaks runs into lines that fit into width. Words are never split,
+// so a single word that is wider than the line overflows it.
+func wrapRuns(runs []pdfRun, size float64, width float64) [][]pdfRun {
+	var lines [][]pdfRun
+	var current []pdfRun
+	lineWidth := 0.0
+
+	for _, run := range runs {
+		for i, word := range strings.Split(run.text, " ") {
+			if i > 0 {
+				word = " " + word
+			}
+			wordWidth := run.font.width(word, size)
+			if lineWidth+wordWidth > width+0.01 && lineWidth > 0 {
+				lines = append(lines, current)
+				current = nil
+				word = strings.TrimLeft(word, " ")
+				wordWidth = run.font.width(word, size)
+				lineWidth = 0
+			}
+			if n := len(current); n > 0 && current[n-1].font == run.font {
+				current[n-1].text += word
+			} else {
+				current = append(current, pdfRun{run.font, word})
+			}
+			lineWidth += wordWidth
+		}
+	}
+	return append(lines, current)
+}
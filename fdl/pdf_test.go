@@ -0,0 +1,114 @@
+package fdl
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// checkPDFStructure verifies that every cross-reference entry points at its object.
+func checkPDFStructure(t *testing.T, pdf []byte) {
+	t.Helper()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("Missing PDF header or trailer")
+	}
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if startxref == nil {
+		t.Fatalf("Missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		expected := fmt.Sprintf("%d 0 obj\n", i+1)
+		if !bytes.HasPrefix(pdf[offset:], []byte(expected)) {
+			t.Errorf("xref entry %d points at %q", i+1, pdf[offset:offset+10])
+		}
+	}
+}
+
+func TestPDFRendererDocument(t *testing.T) {
+	doc := mustParse(t, "@title Guide\n@author Jane (Doe)\n@date 2024-08-18\n@section Setup\n@info Read me\n@code\nx := 1\n@endcode\n")
+
+	var out bytes.Buffer
+	if err := (pdfRenderer{}).RenderDocument(&out, doc); err != nil {
+		t.Fatalf("RenderDocument failed: %v", err)
+	}
+	checkPDFStructure(t, out.Bytes())
+
+	pdf := out.String()
+	if !strings.Contains(pdf, "/Count 2") {
+		t.Errorf("Expected a cover page and one content page")
+	}
+	for _, expected := range []string{"(Guide) Tj", "(Jane \\(Doe\\)) Tj", "(2024-08-18) Tj", "(Setup) Tj", "(Read me) Tj", "(x := 1) Tj"} {
+		if !strings.Contains(pdf, expected) {
+			t.Errorf("Expected PDF to contain %q", expected)
+		}
+	}
+
+	var again bytes.Buffer
+	_ = (pdfRenderer{}).RenderDocument(&again, doc)
+	if !bytes.Equal(out.Bytes(), again.Bytes()) {
+		t.Errorf("Expected identical output for identical input")
+	}
+}
+
+func TestPDFRendererBundle(t *testing.T) {
+	docs := []*Document{mustParse(t, "@title One\n"), mustParse(t, "@title Two\n")}
+
+	var out bytes.Buffer
+	if err := (pdfRenderer{}).RenderBundle(&out, docs); err != nil {
+		t.Fatalf("RenderBundle failed: %v", err)
+	}
+	checkPDFStructure(t, out.Bytes())
+
+	pdf := out.String()
+	if !strings.Contains(pdf, "/Count 4") {
+		t.Errorf("Expected cover and content page for both documents")
+	}
+	if strings.Index(pdf, "(One) Tj") > strings.Index(pdf, "(Two) Tj") {
+		t.Errorf("Expected documents in index order")
+	}
+}
+
+func TestPDFPagination(t *testing.T) {
+	doc := mustParse(t, strings.Repeat("line\n", 200))
+
+	var out bytes.Buffer
+	if err := (pdfRenderer{}).RenderDocument(&out, doc); err != nil {
+		t.Fatalf("RenderDocument failed: %v", err)
+	}
+	if strings.Contains(out.String(), "/Count 2 ") {
+		t.Errorf("Expected 200 lines to span more than one content page")
+	}
+}
+
+func TestWrapRuns(t *testing.T) {
+	runs := []pdfRun{{fontBold, "Info: "}, {fontRegular, "aaa bbb ccc"}}
+	width := fontBold.width("Info: ", 10) + fontRegular.width("aaa bbb", 10)
+
+	lines := wrapRuns(runs, 10, width)
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %v", len(lines), lines)
+	}
+	if len(lines[0]) != 2 || lines[0][1].text != "aaa bbb" {
+		t.Errorf("Unexpected first line %v", lines[0])
+	}
+	if len(lines[1]) != 1 || lines[1][0].text != "ccc" {
+		t.Errorf("Unexpected second line %v", lines[1])
+	}
+}
+
+func TestEncodeWinAnsi(t *testing.T) {
+	result := encodeWinAnsi("Ärger – 5€ 中")
+	expected := []byte{0xC4, 'r', 'g', 'e', 'r', ' ', 0x96, ' ', '5', 0x80, ' ', '?'}
+	if !bytes.Equal(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
@@ -0,0 +1,176 @@
+package fdl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// pdfFont is one of the standard PDF fonts. They need no embedding, which keeps the
+// generated files small and lets the converter work without any external tooling.
+type pdfFont int
+
+const (
+	fontRegular pdfFont = iota
+	fontBold
+	fontItalic
+	fontMono
+	fontBoldItalic
+)
+
+var pdfFontNames = [...]string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Courier", "Helvetica-BoldOblique"}
+
+// Glyph widths (1/1000 em) of the characters 32 to 126, taken from the Adobe font metrics.
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [...]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// width returns the width of s in points when set in font f at the given size.
+func (f pdfFont) width(s string, size float64) float64 {
+	total := 0
+	for _, b := range encodeWinAnsi(s) {
+		switch {
+		case f == fontMono:
+			total += 600
+		case b < 32 || b > 126:
+			total += 556
+		case f == fontBold || f == fontBoldItalic:
+			total += helveticaBoldWidths[b-32]
+		default:
+			total += helveticaWidths[b-32]
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// winAnsiSpecials maps the characters of WinAnsiEncoding that differ from Latin-1.
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// encodeWinAnsi converts s to the single byte encoding used by the standard fonts.
+// Characters that cannot be represented are replaced by "?".
+func encodeWinAnsi(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			encoded = append(encoded, "    "...)
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			encoded = append(encoded, byte(r))
+		case winAnsiSpecials[r] != 0:
+			encoded = append(encoded, winAnsiSpecials[r])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// pdfString encodes s as a PDF literal string.
+func pdfString(s string) string {
+	var escaped strings.Builder
+	escaped.WriteByte('(')
+	for _, b := range encodeWinAnsi(s) {
+		if b == '(' || b == ')' || b == '\\' {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteByte(b)
+	}
+	escaped.WriteByte(')')
+	return escaped.String()
+}
+
+// pdfColor is an RGB color with components between 0 and 1.
+type pdfColor struct {
+	R, G, B float64
+}
+
+var (
+	colorBlack = pdfColor{0, 0, 0}
+	colorRed   = pdfColor{0.8, 0, 0}
+)
+
+// pdfPage collects the content stream of a single page.
+type pdfPage struct {
+	content bytes.Buffer
+	// numbered is false for pages without a page number, such as cover pages.
+	numbered bool
+}
+
+func (p *pdfPage) text(x, y float64, font pdfFont, size float64, color pdfColor, s string) {
+	fmt.Fprintf(&p.content, "BT %.3f %.3f %.3f rg /F%d %.1f Tf %.2f %.2f Td %s Tj ET\n",
+		color.R, color.G, color.B, int(font)+1, size, x, y, pdfString(s))
+}
+
+func (p *pdfPage) fillRect(x, y, w, h float64, color pdfColor) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n", color.R, color.G, color.B, x, y, w, h)
+}
+
+func (p *pdfPage) strokeRect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "0 0 0 RG 0.5 w %.2f %.2f %.2f %.2f re S\n", x, y, w, h)
+}
+
+// writePDF assembles pages into a complete PDF file. The output contains no timestamps,
+// so identical input always produces identical files.
+func writePDF(w io.Writer, title string, pages []*pdfPage) error {
+	var objects []string
+	add := func(obj string) int {
+		objects = append(objects, obj)
+		return len(objects)
+	}
+
+	catalog := add("")
+	pagesRoot := add("")
+	var fonts strings.Builder
+	for i, name := range pdfFontNames {
+		font := add(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fmt.Fprintf(&fonts, "/F%d %d 0 R ", i+1, font)
+	}
+
+	var kids strings.Builder
+	for _, page := range pages {
+		stream := page.content.String()
+		content := add(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(stream), stream))
+		pageObject := add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << %s>> >> /Contents %d 0 R >>", pagesRoot, pdfPageWidth, pdfPageHeight, fonts.String(), content))
+		fmt.Fprintf(&kids, "%d 0 R ", pageObject)
+	}
+	objects[catalog-1] = fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesRoot)
+	objects[pagesRoot-1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", kids.String(), len(pages))
+	info := add(fmt.Sprintf("<< /Title %s /Producer (FastDocumentationLanguage) >>", pdfString(title)))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, catalog, info, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,143 @@
+package fdl
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveReferences links every @ref of documents to its target document and section.
+// It runs after all documents are parsed, so references may point to any document of
+// the build. References whose target doesn't exist are reported as errors.
+func ResolveReferences(documents []*Document, diags *Diagnostics) {
+	byPath := documentsByPath(documents)
+	for _, doc := range documents {
+		resolveDocumentReferences(doc, byPath, diags)
+	}
+}
+
+// documentsByPath indexes documents by their cleaned path with "/" as separator.
+func documentsByPath(documents []*Document) map[string]*Document {
+	byPath := make(map[string]*Document, len(documents))
+	for _, doc := range documents {
+		byPath[filepath.ToSlash(filepath.Clean(doc.Path))] = doc
+	}
+	return byPath
+}
+
+// referencePath returns the path of the document file refers to from doc. Targets are
+// relative to the directory of the referencing document.
+func referencePath(doc *Document, file string) string {
+	return path.Join(path.Dir(filepath.ToSlash(doc.Path)), file)
+}
+
+// resolveDocumentReferences links the references of doc to the documents in byPath.
+func resolveDocumentReferences(doc *Document, byPath map[string]*Document, diags *Diagnostics) {
+	Walk(doc, func(n Node) bool {
+		ref, ok := n.(*Reference)
+		if !ok {
+			return true
+		}
+		if ref.Target == "" {
+			diags.Errorf(ref.Pos, CodeDanglingReference, "@ref without target")
+			return true
+		}
+
+		file, fragment, _ := strings.Cut(ref.Target, "#")
+		target := doc
+		if file != "" {
+			targetPath := referencePath(doc, file)
+			// Even a missing target is a dependency: once it exists, the link changes.
+			doc.addDependency(targetPath)
+			if target = byPath[targetPath]; target == nil {
+				diags.Errorf(ref.Pos, CodeDanglingReference, "@ref to unknown document %s", file)
+				return true
+			}
+		}
+		ref.TargetDoc = target
+		if fragment == "" {
+			return true
+		}
+		if ref.Section = findSection(target, fragment); ref.Section == nil {
+			diags.Errorf(ref.Pos, CodeDanglingReference, "@ref to unknown section #%s in %s", fragment, target.Path)
+		}
+		return true
+	})
+}
+
+// findSection returns the first section of doc with the given id.
+func findSection(doc *Document, id string) *Section {
+	var found *Section
+	Walk(doc, func(n Node) bool {
+		if section, ok := n.(*Section); ok && found == nil && section.ID == id {
+			found = section
+		}
+		return found == nil
+	})
+	return found
+}
+
+// OutputFile returns the path of the file doc is rendered to, relative to the output
+// directory and with "/" as separator. It mirrors the name of the document, or its path
+// relative to the working directory, so docs/api/intro.fdl and docs/guide/intro.fdl don't
+// overwrite each other. Unnamed sources outside of the working directory are placed at
+// the top.
+func OutputFile(doc *Document, extension string) string {
+	source := filepath.ToSlash(filepath.Clean(doc.Name))
+	if doc.Name == "" {
+		source = filepath.ToSlash(filepath.Clean(doc.Path))
+		if !filepath.IsLocal(doc.Path) {
+			source = path.Base(source)
+		}
+	}
+	dir, file := path.Split(source)
+	return dir + convertFileNameToOutputFile(file, extension)
+}
+
+// href returns the link to the target of ref from the output file of doc.
+// Unresolved references link to their target with the .fdl extension replaced.
+func (ref *Reference) href(doc *Document, extension string) string {
+	file, fragment, hasFragment := strings.Cut(ref.Target, "#")
+	if ref.TargetDoc != nil {
+		fragment = ""
+		if ref.Section != nil {
+			fragment = ref.Section.ID
+		}
+		file = ""
+		if ref.TargetDoc != doc {
+			from := path.Dir(filepath.ToSlash(OutputFile(doc, extension)))
+			to := filepath.ToSlash(OutputFile(ref.TargetDoc, extension))
+			rel, err := filepath.Rel(from, to)
+			if err != nil {
+				rel = to
+			}
+			file = filepath.ToSlash(rel)
+		}
+		hasFragment = fragment != ""
+	} else if file != "" {
+		file = strings.TrimSuffix(file, filepath.Ext(file)) + extension
+	}
+	if hasFragment {
+		return file + "#" + fragment
+	}
+	return file
+}
+
+// linkText returns the text shown for ref: its own text, or the title of the target.
+func (ref *Reference) linkText() string {
+	switch {
+	case ref.Text != "":
+		return ref.Text
+	case ref.Section != nil:
+		return ref.Section.Heading()
+	case ref.TargetDoc != nil:
+		return documentTitle(ref.TargetDoc)
+	}
+	return ref.Target
+}
+
+// convertFileNameToOutputFile replaces the extension of fileName, only the last one, so
+// intro.draft.fdl becomes intro.draft.html.
+func convertFileNameToOutputFile(fileName string, extension string) string {
+	return strings.TrimSuffix(fileName, path.Ext(fileName)) + extension
+}
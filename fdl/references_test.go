@@ -0,0 +1,86 @@
+package fdl
+
+import (
+	"strings"
+	"testing"
+)
+
+func parseAt(t *testing.T, path string, input string, diags *Diagnostics) *Document {
+	t.Helper()
+	doc, err := parse(strings.NewReader(input), path, diags)
+	if err != nil {
+		t.Fatalf("parse() failed: %v", err)
+	}
+	return doc
+}
+
+func TestResolveReferences(t *testing.T) {
+	diags := &Diagnostics{}
+	guide := parseAt(t, "guide.fdl", "@title Guide\n"+
+		"@ref docs/install.fdl#configuration\n"+
+		"@link docs/install.fdl Installation guide\n"+
+		"@ref #usage\n"+
+		"@section Usage\n", diags)
+	install := parseAt(t, "docs/install.fdl", "@title Install\n"+
+		"@section Configuration\n"+
+		"@ref ../guide.fdl#usage See usage\n"+
+		"@ref missing.fdl\n"+
+		"@ref ../guide.fdl#missing\n"+
+		"@ref\n", diags)
+
+	ResolveReferences([]*Document{guide, install}, diags)
+
+	expected := []string{
+		"docs/install.fdl:4:1: error[FDL116]: @ref to unknown document missing.fdl",
+		"docs/install.fdl:5:1: error[FDL116]: @ref to unknown section #missing in guide.fdl",
+		"docs/install.fdl:6:1: error[FDL116]: @ref without target",
+	}
+	got := diags.Sorted()
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d diagnostics, got %v", len(expected), got)
+	}
+	for i, diagnostic := range got {
+		if diagnostic.String() != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], diagnostic.String())
+		}
+	}
+
+	html, _ := renderHTMLBody(guide)
+	for _, link := range []string{
+		"<p><a href='docs/install.html#configuration'>Configuration</a></p>",
+		"<p><a href='docs/install.html'>Installation guide</a></p>",
+		"<p><a href='#usage'>Usage</a></p>",
+	} {
+		if !strings.Contains(html, link) {
+			t.Errorf("Expected %s in %s", link, html)
+		}
+	}
+
+	markdown := renderMarkdown(install)
+	if !strings.Contains(markdown, "[See usage](../guide.md#usage)") {
+		t.Errorf("Expected a Markdown link to guide.md#usage, got %s", markdown)
+	}
+}
+
+func TestUnresolvedReferenceHref(t *testing.T) {
+	doc := mustParse(t, "@ref install.fdl#setup\n@ref #intro\n")
+
+	html, _ := renderHTMLBody(doc)
+	expected := "<p><a href='install.html#setup'>install.fdl#setup</a></p>\n<p><a href='#intro'>#intro</a></p>\n"
+	if html != expected {
+		t.Errorf("Expected %q, got %q", expected, html)
+	}
+}
+
+func TestConvertFileNameToOutputFile(t *testing.T) {
+	tests := map[string]string{
+		"example.fdl":     "example.html",
+		"intro.draft.fdl": "intro.draft.html",
+		"no-extension":    "no-extension.html",
+	}
+	for input, expected := range tests {
+		if result := convertFileNameToOutputFile(input, ".html"); result != expected {
+			t.Errorf("Expected %s, got %s", expected, result)
+		}
+	}
+}
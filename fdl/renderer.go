@@ -0,0 +1,109 @@
+package fdl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Renderer converts parsed documents into one output format.
+type Renderer interface {
+	// Extension is the file extension of the generated files, including the dot.
+	Extension() string
+	// RenderDocument writes the converted document to w.
+	RenderDocument(w io.Writer, doc *Document) error
+	// RenderIndex writes the overview page that links all converted documents.
+	RenderIndex(w io.Writer, chapters []Chapter) error
+}
+
+// Bundler is implemented by renderers that can additionally combine all documents
+// into a single output file.
+type Bundler interface {
+	RenderBundle(w io.Writer, docs []*Document) error
+}
+
+// Settings are the project wide settings passed to every renderer.
+type Settings struct {
+	// Title is shown on the index page, "Documentation" if empty.
+	Title string
+	// Theme selects one of the ThemeNames, "" is the default theme. Only the HTML
+	// backend uses it.
+	Theme string
+	// Draft adds a banner marking the output as development documentation.
+	Draft bool
+}
+
+func (s Settings) title() string {
+	if s.Title == "" {
+		return "Documentation"
+	}
+	return s.Title
+}
+
+// Chapter is one entry of the generated index.
+type Chapter struct {
+	Number int
+	Name   string
+	File   string
+	// Folder is the directory of File, "" for documents at the top of the output.
+	Folder string
+}
+
+// indexEntry is a line of the index: a folder or a chapter, indented by Depth.
+type indexEntry struct {
+	Depth int
+	// Folder is the name of a folder, "" if the entry is Chapter.
+	Folder  string
+	Chapter Chapter
+}
+
+// indexEntries groups chapters by folder. Every folder is listed once, before its first
+// chapter, and its chapters and subfolders follow one level deeper. The chapters of a
+// folder must follow each other.
+func indexEntries(chapters []Chapter) []indexEntry {
+	var entries []indexEntry
+	var open []string
+	for _, c := range chapters {
+		var folders []string
+		if c.Folder != "" {
+			folders = strings.Split(c.Folder, "/")
+		}
+		common := 0
+		for common < len(open) && common < len(folders) && open[common] == folders[common] {
+			common++
+		}
+		for depth := common; depth < len(folders); depth++ {
+			entries = append(entries, indexEntry{Depth: depth, Folder: folders[depth]})
+		}
+		entries = append(entries, indexEntry{Depth: len(folders), Chapter: c})
+		open = folders
+	}
+	return entries
+}
+
+// renderers holds all output backends by the name passed to NewRenderer.
+var renderers = map[string]func(site Settings) Renderer{
+	"html":     func(site Settings) Renderer { return htmlRenderer{site: site} },
+	"markdown": func(site Settings) Renderer { return markdownRenderer{site: site} },
+	"pdf":      func(site Settings) Renderer { return pdfRenderer{site: site} },
+}
+
+// NewRenderer returns the output backend with the given name, see RendererNames.
+func NewRenderer(format string, site Settings) (Renderer, error) {
+	newFunc, ok := renderers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q (available: %s)", format, strings.Join(RendererNames(), ", "))
+	}
+	return newFunc(site), nil
+}
+
+// RendererNames returns the names of all output backends, sorted.
+func RendererNames() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,99 @@
+package fdl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewRenderer(t *testing.T) {
+	renderer, err := NewRenderer("html", Settings{})
+	if err != nil {
+		t.Fatalf("NewRenderer(html) failed: %v", err)
+	}
+	if renderer.Extension() != ".html" {
+		t.Errorf("Expected extension .html, got %s", renderer.Extension())
+	}
+
+	if _, err := NewRenderer("docx", Settings{}); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+func TestHTMLRendererIndex(t *testing.T) {
+	var out bytes.Buffer
+	chapters := []Chapter{{Number: 1, Name: "intro", File: "intro.html"}, {Number: 2, Name: "a<b>", File: "a<b>.html"}}
+	if err := (htmlRenderer{}).RenderIndex(&out, chapters); err != nil {
+		t.Fatalf("RenderIndex failed: %v", err)
+	}
+
+	expected := "<html><body><h1>Documentation <br> Table Of Content</h1><ul>" +
+		"<li> <a href='intro.html'>1 intro</a></li><li> <a href='a&lt;b&gt;.html'>2 a&lt;b&gt;</a></li></ul></body></html>"
+	if out.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, out.String())
+	}
+}
+
+func TestSiteSettings(t *testing.T) {
+	site := Settings{Title: "Manual <v2>", Theme: "dark"}
+	chapters := []Chapter{{Number: 1, Name: "intro", File: "intro.html"}}
+
+	var html bytes.Buffer
+	if err := (htmlRenderer{site: site}).RenderIndex(&html, chapters); err != nil {
+		t.Fatalf("RenderIndex failed: %v", err)
+	}
+	if !strings.HasPrefix(html.String(), "<html><body><h1>Manual &lt;v2&gt; <br> Table Of Content</h1>") {
+		t.Errorf("Expected the site title on the index page, got %s", html.String())
+	}
+	if !strings.HasSuffix(html.String(), htmlThemes["dark"]+"</body></html>") {
+		t.Errorf("Expected the theme stylesheet on the index page, got %s", html.String())
+	}
+
+	var markdown bytes.Buffer
+	if err := (markdownRenderer{site: site}).RenderIndex(&markdown, chapters); err != nil {
+		t.Fatalf("RenderIndex failed: %v", err)
+	}
+	if !strings.HasPrefix(markdown.String(), "# Manual \\<v2\\>\n") {
+		t.Errorf("Expected the site title on the index page, got %s", markdown.String())
+	}
+}
+
+func TestIndexEntries(t *testing.T) {
+	chapters := []Chapter{
+		{Number: 1, Name: "readme", File: "readme.html"},
+		{Number: 2, Name: "intro", File: "docs/api/intro.html", Folder: "docs/api"},
+		{Number: 3, Name: "a", File: "docs/api/v2/a.html", Folder: "docs/api/v2"},
+		{Number: 4, Name: "intro", File: "docs/guide/intro.html", Folder: "docs/guide"},
+	}
+	var got []string
+	for _, entry := range indexEntries(chapters) {
+		name := entry.Folder + "/"
+		if entry.Folder == "" {
+			name = entry.Chapter.File
+		}
+		got = append(got, strings.Repeat(" ", entry.Depth)+name)
+	}
+	expected := "readme.html|docs/| api/|  docs/api/intro.html|  v2/|   docs/api/v2/a.html| guide/|  docs/guide/intro.html"
+	if result := strings.Join(got, "|"); result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+
+	var html bytes.Buffer
+	if err := (htmlRenderer{}).RenderIndex(&html, chapters[:2]); err != nil {
+		t.Fatalf("RenderIndex failed: %v", err)
+	}
+	list := "<ul><li> <a href='readme.html'>1 readme</a></li><li>docs<ul><li>api<ul><li> <a href='docs/api/intro.html'>2 intro</a>" +
+		"</li></ul></li></ul></li></ul>"
+	if !strings.Contains(html.String(), list) {
+		t.Errorf("Expected %s in %s", list, html.String())
+	}
+
+	var markdown bytes.Buffer
+	if err := (markdownRenderer{}).RenderIndex(&markdown, chapters[:2]); err != nil {
+		t.Fatalf("RenderIndex failed: %v", err)
+	}
+	list = "1. [readme](readme.html)\n- **docs/**\n   - **api/**\n      2. [intro](docs/api/intro.html)\n"
+	if !strings.Contains(markdown.String(), list) {
+		t.Errorf("Expected %s in %s", list, markdown.String())
+	}
+}
@@ -0,0 +1,105 @@
+package fdl
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// slug turns a section title into the form used in anchors: lower case letters and
+// digits, everything else collapsed into single dashes.
+//
+//	"Getting Started"  getting-started
+//	"What's new?"      what-s-new
+func slug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "section"
+	}
+	return b.String()
+}
+
+// sectionRegistry assigns the section IDs of a document in source order. A title whose
+// slug is already taken gets the first free suffix, so two "Intro" sections become
+// intro and intro-1. The IDs only depend on the document, which keeps the output of
+// identical inputs identical.
+type sectionRegistry struct {
+	taken map[string]bool
+}
+
+func (r *sectionRegistry) register(title string) string {
+	if r.taken == nil {
+		r.taken = make(map[string]bool)
+	}
+	base := slug(title)
+	id := base
+	for i := 1; r.taken[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	r.taken[id] = true
+	return id
+}
+
+// collectSections returns all sections of doc in document order.
+func collectSections(doc *Document) []*Section {
+	var sections []*Section
+	Walk(doc, func(n Node) bool {
+		if section, ok := n.(*Section); ok {
+			sections = append(sections, section)
+		}
+		return true
+	})
+	return sections
+}
+
+// numberSections numbers all sections of doc hierarchically: 1, 1.1, 1.1.1, 2, ...
+func numberSections(doc *Document) {
+	var counters []int
+	for _, section := range collectSections(doc) {
+		for len(counters) < section.Level {
+			counters = append(counters, 0)
+		}
+		counters = counters[:section.Level]
+		counters[section.Level-1]++
+		// A subsection without a surrounding section counts as part of section 1.
+		for i := range counters {
+			if counters[i] == 0 {
+				counters[i] = 1
+			}
+		}
+
+		parts := make([]string, len(counters))
+		for i, counter := range counters {
+			parts[i] = strconv.Itoa(counter)
+		}
+		section.Number = strings.Join(parts, ".")
+	}
+}
+
+// tocDepths returns the nesting depth of every section in a table of contents. A section
+// is nested below the closest preceding section of a lower level, so the depth grows by
+// at most one from one entry to the next.
+func tocDepths(sections []*Section) []int {
+	depths := make([]int, len(sections))
+	var levels []int
+	for i, section := range sections {
+		for len(levels) > 0 && levels[len(levels)-1] >= section.Level {
+			levels = levels[:len(levels)-1]
+		}
+		depths[i] = len(levels)
+		levels = append(levels, section.Level)
+	}
+	return depths
+}
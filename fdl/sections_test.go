@@ -0,0 +1,133 @@
+package fdl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const nestedSections = "@section Setup\n@subsection Linux\n@subsubsection Debian\n@subsection Windows\n@section Usage"
+
+func TestSectionHierarchy(t *testing.T) {
+	doc := mustParse(t, nestedSections+"\nText")
+
+	setup := doc.Children[0].(*Section)
+	usage := doc.Children[1].(*Section)
+	if len(doc.Children) != 2 || setup.Title != "Setup" || usage.Title != "Usage" {
+		t.Fatalf("Expected sections Setup and Usage at the top, got %v", doc.Children)
+	}
+	linux := setup.Children[0].(*Section)
+	if len(setup.Children) != 2 || linux.Level != 2 || setup.Children[1].(*Section).Title != "Windows" {
+		t.Errorf("Expected subsections Linux and Windows below Setup, got %v", setup.Children)
+	}
+	if debian := linux.Children[0].(*Section); debian.Level != 3 || debian.Title != "Debian" {
+		t.Errorf("Expected Debian below Linux, got %v", linux.Children)
+	}
+	if _, ok := usage.Children[0].(*Paragraph); !ok {
+		t.Errorf("Expected the text inside Usage, got %v", usage.Children)
+	}
+}
+
+func TestNumberSections(t *testing.T) {
+	// Ein Unterabschnitt ohne Abschnitt davor wird trotzdem fortlaufend nummeriert.
+	doc := mustParse(t, "@subsection Preface\n"+nestedSections)
+	numberSections(doc)
+
+	var got []string
+	for _, section := range collectSections(doc) {
+		got = append(got, section.Heading())
+	}
+	expected := "1.1 Preface|2 Setup|2.1 Linux|2.1.1 Debian|2.2 Windows|3 Usage"
+	if strings.Join(got, "|") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(got, "|"))
+	}
+}
+
+func TestNestedTableOfContents(t *testing.T) {
+	doc := mustParse(t, nestedSections)
+
+	expected := "<h2>Table of Contents</h2><ul><li><a href='#setup'>Setup</a><ul><li><a href='#linux'>Linux</a>" +
+		"<ul><li><a href='#debian'>Debian</a></li></ul></li><li><a href='#windows'>Windows</a></li></ul></li>" +
+		"<li><a href='#usage'>Usage</a></li></ul>"
+	body, sections := renderHTMLBody(doc)
+	if result := generateTableOfContents(sections); result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+	if !strings.Contains(body, "<h3 id='linux'>Linux</h3>") || !strings.Contains(body, "<h4 id='debian'>Debian</h4>") {
+		t.Errorf("Expected h3 and h4 headings, got %s", body)
+	}
+
+	expected = "- [Setup](#setup)\n  - [Linux](#linux)\n    - [Debian](#debian)\n  - [Windows](#windows)\n- [Usage](#usage)"
+	markdown := renderMarkdown(doc)
+	if !strings.Contains(markdown, expected) || !strings.Contains(markdown, "#### <a id=\"debian\"></a>Debian") {
+		t.Errorf("Expected nested list and level 4 heading, got %s", markdown)
+	}
+}
+
+func TestNumberedTableOfContents(t *testing.T) {
+	doc := mustParse(t, nestedSections)
+	numberSections(doc)
+
+	body, sections := renderHTMLBody(doc)
+	if !strings.Contains(generateTableOfContents(sections), "<a href='#debian'>1.1.1 Debian</a>") {
+		t.Errorf("Expected numbered entry, got %s", generateTableOfContents(sections))
+	}
+	if !strings.Contains(body, "<h2 id='usage'>2 Usage</h2>") {
+		t.Errorf("Expected numbered heading, got %s", body)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"Introduction":    "introduction",
+		"Getting Started": "getting-started",
+		"What's new?":     "what-s-new",
+		"  C++ & Go  ":    "c-go",
+		"Über uns":        "über-uns",
+		"snake_case":      "snake_case",
+		"???":             "section",
+	}
+	for title, expected := range tests {
+		if result := slug(title); result != expected {
+			t.Errorf("slug(%q): Expected %s, got %s", title, expected, result)
+		}
+	}
+}
+
+func TestDuplicateSectionIDs(t *testing.T) {
+	// "Intro 1" ist bereits durch das zweite "Intro" belegt.
+	doc := mustParse(t, "@section Intro\n@subsection Intro\n@section Intro 1\n@section intro")
+
+	var got []string
+	for _, section := range collectSections(doc) {
+		got = append(got, section.ID)
+	}
+	expected := "intro|intro-1|intro-1-1|intro-2"
+	if strings.Join(got, "|") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(got, "|"))
+	}
+}
+
+func TestTableOfContentsIsStable(t *testing.T) {
+	input := "@section Zeta\n@section Alpha\n@subsection Mu\n@section Alpha\n@section Beta"
+	expected := "<h2>Table of Contents</h2><ul><li><a href='#zeta'>Zeta</a></li><li><a href='#alpha'>Alpha</a>" +
+		"<ul><li><a href='#mu'>Mu</a></li></ul></li><li><a href='#alpha-1'>Alpha</a></li><li><a href='#beta'>Beta</a></li></ul>"
+	var first bytes.Buffer
+	if err := (htmlRenderer{}).RenderDocument(&first, mustParse(t, input)); err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		doc := mustParse(t, input)
+		_, sections := renderHTMLBody(doc)
+		if result := generateTableOfContents(sections); result != expected {
+			t.Fatalf("Expected %s, got %s", expected, result)
+		}
+		var result bytes.Buffer
+		if err := (htmlRenderer{}).RenderDocument(&result, doc); err != nil {
+			t.Fatalf("Failed to render: %v", err)
+		}
+		if !bytes.Equal(result.Bytes(), first.Bytes()) {
+			t.Fatalf("Expected identical output for identical input, got\n%s\nand\n%s", first.String(), result.String())
+		}
+	}
+}
@@ -2,4 +2,4 @@ module FastDocumentationLanguage
 
 go 1.22rc2
 
-require github.com/common-nighthawk/go-figure v0.0.0-20210622060536-734e95fb86be // indirect
+require github.com/common-nighthawk/go-figure v0.0.0-20210622060536-734e95fb86be
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// defaultExcludes are skipped in every input, at any depth.
+var defaultExcludes = []string{".git", "node_modules", "vendor"}
+
+// ignoreFiles are read in every searched directory. Their rules use the .gitignore
+// syntax and apply to the directory and everything below it. Rules of a .fdlignore
+// come last and can therefore override the .gitignore, e.g. with "!docs/".
+var ignoreFiles = []string{".gitignore", ".fdlignore"}
+
+// ignoreRule is a single pattern of an ignore file.
+type ignoreRule struct {
+	// base is the directory of the ignore file relative to the working directory,
+	// "" for the working directory itself.
+	base     string
+	segments []string
+	// negate re-includes what an earlier rule ignored ("!pattern").
+	negate bool
+	// dirOnly rules only match directories ("pattern/").
+	dirOnly bool
+	// anchored rules match the path below base, the others the name of a file or
+	// directory at any depth.
+	anchored bool
+}
+
+// newIgnoreRule parses a line of an ignore file in base. It returns false for blank
+// lines and comments.
+func newIgnoreRule(base string, line string) (ignoreRule, bool) {
+	pattern := strings.TrimSpace(line)
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return ignoreRule{}, false
+	}
+	rule := ignoreRule{base: base}
+	pattern, rule.negate = strings.CutPrefix(pattern, "!")
+	pattern, rule.dirOnly = strings.CutSuffix(pattern, "/")
+	rule.anchored = strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return ignoreRule{}, false
+	}
+	rule.segments = strings.Split(pattern, "/")
+	return rule, true
+}
+
+// matches reports whether the rule matches rel, a path relative to the working directory
+// with "/" as separator.
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+	if !r.anchored {
+		return matchGlob(r.segments, []string{path.Base(rel)})
+	}
+	return matchGlob(r.segments, strings.Split(rel, "/"))
+}
+
+// matchGlob matches the segments of a path against the segments of a pattern. A "**"
+// segment matches any number of segments, the others follow path.Match.
+func matchGlob(pattern []string, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchGlob(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, err := path.Match(pattern[0], name[0])
+	return err == nil && ok && matchGlob(pattern[1:], name[1:])
+}
+
+// fileFilter decides which files and directories the search for documents visits.
+type fileFilter struct {
+	cwd        string
+	outputPath string
+	// rules are the default excludes and the rules of all ignore files read so far,
+	// the last matching rule wins.
+	rules []ignoreRule
+	// exclude and include are the glob patterns of --exclude and --include, relative to
+	// the working directory.
+	exclude [][]string
+	include [][]string
+}
+
+func newFileFilter(cwd string, setFlags options) *fileFilter {
+	f := &fileFilter{cwd: cwd}
+	if outputPath, err := outputDirectoryPath(setFlags.Directory); err == nil {
+		f.outputPath = outputPath
+	}
+	for _, name := range defaultExcludes {
+		rule, _ := newIgnoreRule("", name)
+		f.rules = append(f.rules, rule)
+	}
+	for _, pattern := range setFlags.Exclude {
+		f.exclude = append(f.exclude, globSegments(pattern))
+	}
+	for _, pattern := range setFlags.Include {
+		f.include = append(f.include, globSegments(pattern))
+	}
+	return f
+}
+
+func globSegments(pattern string) []string {
+	return strings.Split(strings.TrimPrefix(filepath.ToSlash(filepath.Clean(pattern)), "./"), "/")
+}
+
+// rel returns path relative to the working directory, with "/" as separator.
+func (f *fileFilter) rel(path string) string {
+	rel, err := filepath.Rel(f.cwd, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// readIgnoreFiles adds the rules of the ignore files in the directory dir.
+func (f *fileFilter) readIgnoreFiles(dir string) error {
+	base := f.rel(dir)
+	if base == "." {
+		base = ""
+	}
+	for _, name := range ignoreFiles {
+		file, err := os.Open(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if rule, ok := newIgnoreRule(base, scanner.Text()); ok {
+				f.rules = append(f.rules, rule)
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readParentIgnoreFiles reads the ignore files from the working directory down to the
+// parent of root, so they apply to an input below the working directory as well.
+func (f *fileFilter) readParentIgnoreFiles(root string) error {
+	rel := f.rel(root)
+	if rel == "." || !filepath.IsLocal(rel) {
+		return nil
+	}
+	dir := f.cwd
+	if err := f.readIgnoreFiles(dir); err != nil {
+		return err
+	}
+	if parent := path.Dir(rel); parent != "." {
+		for _, segment := range strings.Split(parent, "/") {
+			dir = filepath.Join(dir, segment)
+			if err := f.readIgnoreFiles(dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// excluded reports whether path is excluded with --exclude, or lies below an excluded path.
+func (f *fileFilter) excluded(path string) bool {
+	segments := strings.Split(f.rel(path), "/")
+	for _, pattern := range f.exclude {
+		for i := 1; i <= len(segments); i++ {
+			if matchGlob(pattern, segments[:i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ignored reports whether path is the output directory, or the output directory of another
+// build, or matched by the default excludes or an ignore file.
+func (f *fileFilter) ignored(path string, isDir bool) bool {
+	if path == f.outputPath || isDir && hasBuildMarker(path) {
+		return true
+	}
+	rel := f.rel(path)
+	ignored := false
+	for _, rule := range f.rules {
+		if rule.matches(rel, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// included reports whether the file at path matches --include. Without include
+// patterns every file is included.
+func (f *fileFilter) included(path string) bool {
+	if len(f.include) == 0 {
+		return true
+	}
+	segments := strings.Split(f.rel(path), "/")
+	for _, pattern := range f.include {
+		if matchGlob(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreRule(t *testing.T) {
+	tests := []struct {
+		base    string
+		line    string
+		path    string
+		isDir   bool
+		matches bool
+	}{
+		{"", "drafts", "docs/drafts", true, true},
+		{"", "drafts", "docs/drafts.fdl", false, false},
+		{"", "*.tmp.fdl", "docs/api/x.tmp.fdl", false, true},
+		{"", "build/", "docs/build", false, false},
+		{"", "build/", "docs/build", true, true},
+		{"", "/build", "docs/build", true, false},
+		{"", "/build", "build", true, true},
+		{"", "docs/*.fdl", "docs/a.fdl", false, true},
+		{"", "docs/*.fdl", "docs/api/a.fdl", false, false},
+		{"", "docs/**/*.fdl", "docs/a.fdl", false, true},
+		{"", "docs/**/*.fdl", "docs/api/v2/a.fdl", false, true},
+		{"", "**/old", "docs/api/old", true, true},
+		{"docs", "/old", "docs/old", true, true},
+		{"docs", "/old", "old", true, false},
+		{"docs", "old", "api/old", true, false},
+	}
+	for _, test := range tests {
+		rule, ok := newIgnoreRule(test.base, test.line)
+		if !ok {
+			t.Fatalf("Expected %q to be a rule", test.line)
+		}
+		if got := rule.matches(test.path, test.isDir); got != test.matches {
+			t.Errorf("Expected %q in %q to match %s: %t, got %t", test.line, test.base, test.path, test.matches, got)
+		}
+	}
+
+	for _, line := range []string{"", "  ", "# comment", "/", "!"} {
+		if _, ok := newIgnoreRule("", line); ok {
+			t.Errorf("Expected %q to be no rule", line)
+		}
+	}
+}
+
+func TestFindDocumentsFilter(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		".gitignore":                    "*.tmp.fdl\ngenerated/\n",
+		".fdlignore":                    "!docs/generated/\n",
+		"root.fdl":                      "",
+		"scratch.tmp.fdl":               "",
+		"node_modules/pkg/readme.fdl":   "",
+		"vendor/lib/doc.fdl":            "",
+		".git/info.fdl":                 "",
+		"documentation/copy.fdl":        "",
+		"generated/api.fdl":             "",
+		"site/.fdl-build":               "",
+		"site/copy.fdl":                 "",
+		"docs/a.fdl":                    "",
+		"docs/generated/b.fdl":          "",
+		"docs/api/.fdlignore":           "# Nur für die API\ninternal.fdl\n",
+		"docs/api/internal.fdl":         "",
+		"docs/api/public.fdl":           "",
+		"docs/api/drafts/next.fdl":      "",
+		"docs/api/draftsman/notes.fdl":  "",
+		"docs/api/drafts/next.tmp.fdl":  "",
+		"docs/node_modules/package.fdl": "",
+	})
+
+	find := func(setFlags options) []string {
+		t.Helper()
+		cwd, _ := os.Getwd()
+		setFlags.FileExtension = ".fdl"
+		setFlags.Directory = "documentation"
+		files, err := findDocuments(setFlags)
+		if err != nil {
+			t.Fatalf("findDocuments failed: %v", err)
+		}
+		var got []string
+		for _, file := range files {
+			rel, _ := filepath.Rel(cwd, file)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		return got
+	}
+
+	// Standardmäßig ausgeschlossene Verzeichnisse, das Ausgabeverzeichnis und die Ignore-Dateien.
+	expected := []string{"root.fdl", "docs/a.fdl", "docs/api/public.fdl", "docs/api/drafts/next.fdl", "docs/api/draftsman/notes.fdl", "docs/generated/b.fdl"}
+	if got := find(options{}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Die Ignore-Dateien über einem Eingabeverzeichnis gelten ebenfalls.
+	expected = []string{"docs/api/public.fdl", "docs/api/drafts/next.fdl", "docs/api/draftsman/notes.fdl"}
+	if got := find(options{Inputs: []string{"docs/api"}}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Ein explizit genanntes Eingabeverzeichnis wird durchsucht, auch wenn es sonst ignoriert wäre.
+	expected = []string{"vendor/lib/doc.fdl"}
+	if got := find(options{Inputs: []string{"vendor"}}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	expected = []string{"docs/a.fdl", "docs/api/public.fdl"}
+	got := find(options{Inputs: []string{"docs"}, Include: []string{"docs/**/*.fdl"}, Exclude: []string{"docs/*/drafts*", "docs/generated"}})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
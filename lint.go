@@ -0,0 +1,68 @@
+package main
+
+import (
+	"FastDocumentationLanguage/fdl"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// runLint checks all documents without generating output and returns the exit code:
+// 0 if nothing was found, 1 if there is at least one finding. The report is written in
+// the format setFlags.Report, which runLintCommand has validated.
+func runLint(setFlags options, w io.Writer) int {
+	diags := &fdl.Diagnostics{}
+	// Drafts and the content for other audiences are checked as well, they are published
+	// in the development documentation and with other variables and tags.
+	setFlags.Devdoc = true
+	setFlags.AllBranches = true
+	documents, err := parseDocuments(setFlags, diags)
+	if err != nil {
+		diags.Errorf(fdl.Pos{}, fdl.CodeFileSystem, "%v", err)
+	}
+	for _, doc := range documents {
+		fdl.Lint(doc, diags)
+	}
+	fdl.ResolveReferences(documents, diags)
+
+	if setFlags.Report == "json" {
+		if err := writeJSONReport(w, diags); err != nil {
+			fmt.Fprintln(w, err)
+			return exitFindings
+		}
+	} else {
+		diags.Print(w)
+	}
+
+	if diags.Len() > 0 {
+		return exitFindings
+	}
+	return exitOK
+}
+
+// jsonDiagnostic is the machine-readable form of a fdl.Diagnostic.
+type jsonDiagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+func writeJSONReport(w io.Writer, diags *fdl.Diagnostics) error {
+	report := []jsonDiagnostic{}
+	for _, d := range diags.Sorted() {
+		report = append(report, jsonDiagnostic{
+			File:     d.Pos.File,
+			Line:     d.Pos.Line,
+			Column:   d.Pos.Column,
+			Severity: d.Severity.String(),
+			Code:     d.Code,
+			Message:  d.Message,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestRunLint(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+
+	if err := os.WriteFile("good.fdl", []byte("@title Good\n@section A\n"), 0644); err != nil {
+		t.Fatalf("Could not create test file: %v", err)
+	}
+
+	var out bytes.Buffer
+	if code := runLint(options{FileExtension: ".fdl"}, &out); code != 0 {
+		t.Errorf("Expected exit code 0 for a clean document, got %d: %s", code, out.String())
+	}
+
+	if err := os.WriteFile("bad.fdl", []byte("@title Bad\n@list\n@item a\n"), 0644); err != nil {
+		t.Fatalf("Could not create test file: %v", err)
+	}
+
+	out.Reset()
+	if code := runLint(options{FileExtension: ".fdl", Report: "json"}, &out); code != 1 {
+		t.Errorf("Expected exit code 1 for findings, got %d", code)
+	}
+	var report []jsonDiagnostic
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected a JSON report, got %s: %v", out.String(), err)
+	}
+	expected := jsonDiagnostic{File: "bad.fdl", Line: 2, Column: 1, Severity: "error", Code: "FDL112", Message: "@list is never closed with @endlist"}
+	if len(report) != 1 || report[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, report)
+	}
+
+	// Lint must not generate any output.
+	if _, err := os.Stat("documentation"); !os.IsNotExist(err) {
+		t.Errorf("Expected no output directory to be created")
+	}
+}
+
+func TestRunLintAllBranches(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"guide.fdl": "@title Guide\n@if audience=internal\n@row a|b\n@bogus\n@else\nPublic\n@endif\n"})
+
+	// Die Zweige für andere Zielgruppen werden ebenfalls geprüft, jeder Fund nur einmal.
+	for _, vars := range []map[string]string{nil, {"audience": "internal"}} {
+		var out bytes.Buffer
+		if code := runLint(options{FileExtension: ".fdl", Vars: vars, Report: "json"}, &out); code != 1 {
+			t.Errorf("Expected exit code 1 with %v, got %d", vars, code)
+		}
+		var report []jsonDiagnostic
+		if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+			t.Fatalf("Expected a JSON report, got %s: %v", out.String(), err)
+		}
+		var codes []string
+		for _, d := range report {
+			codes = append(codes, d.Code)
+		}
+		if expected := []string{"FDL102", "FDL114"}; !reflect.DeepEqual(codes, expected) {
+			t.Errorf("Expected %v with %v, got %+v", expected, vars, report)
+		}
+	}
+}
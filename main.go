@@ -1,352 +1,504 @@
 package main
 
 import (
-	"bufio"
+	"FastDocumentationLanguage/fdl"
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/common-nighthawk/go-figure"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
+	"sync/atomic"
 )
 
-type flag struct {
-	FileExtension string
-	Directory     string
-	Devdoc        bool
+func getFilePath(fileExtension string) ([]string, error) {
+	return findDocuments(options{FileExtension: fileExtension})
 }
 
-func getFlagsFromCli() flag {
-	var setFlags = flag{
-		FileExtension: ".fdl",
-		Directory:     "/documentation",
-		Devdoc:        false,
-	}
-
-	if len(os.Args) != 0 {
-		for _, arg := range os.Args {
-			if strings.HasPrefix(arg, "--file-extension") || strings.HasPrefix(arg, "-fe") {
-				extension := strings.Split(arg, "=")
-				setFlags.FileExtension = extension[1]
-			} else if strings.HasPrefix(arg, "--directory") || strings.HasPrefix(arg, "-dir") {
-				dir := strings.Split(arg, "=")
-				setFlags.Directory = dir[1]
-			} else if strings.HasPrefix(arg, "--development-documentation") || strings.HasPrefix(arg, "-dev-doc") {
-				setFlags.Devdoc = true
-			}
-		}
-	} else {
-		log.Println("The System is used with default values.")
-	}
-
-	return setFlags
+// findDocuments returns the absolute paths of all documents below the input directories
+// of setFlags, without the excluded ones, see fileFilter. Without inputs the working
+// directory is searched.
+// Fragments, which are only included by other documents, are skipped.
+func findDocuments(setFlags options) ([]string, error) {
+	return findFiles(setFlags, false)
 }
-func getFilePath(fileExtension string) []string {
+
+// findFiles is like findDocuments, but returns fragments as well if withFragments is set.
+func findFiles(setFlags options, withFragments bool) ([]string, error) {
 	var pathSlices []string
 
-	if fileExtension == "" {
-		log.Panic("No File Extensions is set.")
+	if setFlags.FileExtension == "" {
+		return nil, errors.New("no file extension is set")
 	}
 	cwd, err := os.Getwd()
 	if err != nil {
-		log.Panic("Error until reading the directories: ", err)
-		return nil
+		return nil, fmt.Errorf("can't read the working directory: %w", err)
+	}
+	inputs := setFlags.Inputs
+	if len(inputs) == 0 {
+		inputs = []string{"."}
 	}
 
-	_ = filepath.WalkDir(cwd, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
+	found := make(map[string]bool)
+	for _, input := range inputs {
+		first := len(pathSlices)
+		root := input
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(cwd, root)
 		}
+		filter := newFileFilter(cwd, setFlags)
+		if err := filter.readParentIgnoreFiles(root); err != nil {
+			return nil, fmt.Errorf("can't read the ignore files of %s: %w", input, err)
+		}
+		err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// The default excludes and ignore files don't apply to the input itself,
+			// it was named explicitly.
+			if filter.excluded(path) || (path != root && filter.ignored(path, d.IsDir())) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return filter.readIgnoreFiles(path)
+			}
 
-		if !d.IsDir() && strings.HasSuffix(d.Name(), fileExtension) {
-			pathSlices = append(pathSlices, path)
+			if strings.HasSuffix(d.Name(), setFlags.FileExtension) && filter.included(path) && (withFragments || !fdl.IsFragment(path)) && !found[path] {
+				found[path] = true
+				pathSlices = append(pathSlices, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can't search for documents in %s: %w", input, err)
 		}
-		return nil
-	})
-	if err != nil {
-		log.Panic(err)
+		sort.SliceStable(pathSlices[first:], func(i, j int) bool {
+			return lessDocumentPath(pathSlices[first+i], pathSlices[first+j])
+		})
 	}
 
-	return pathSlices
+	return pathSlices, nil
 }
 
-func convertFileNameToHTMLFile(fileName string) string {
-	filenameSlices := strings.Split(fileName, ".")
-	return filenameSlices[0] + ".html"
+// lessDocumentPath orders the documents of a directory before the ones in its
+// subdirectories, so the documents of each folder follow each other in the index.
+func lessDocumentPath(a string, b string) bool {
+	dirA := strings.Split(filepath.Dir(a), string(filepath.Separator))
+	dirB := strings.Split(filepath.Dir(b), string(filepath.Separator))
+	for i := 0; i < len(dirA) && i < len(dirB); i++ {
+		if dirA[i] != dirB[i] {
+			return dirA[i] < dirB[i]
+		}
+	}
+	if len(dirA) != len(dirB) {
+		return len(dirA) < len(dirB)
+	}
+	return filepath.Base(a) < filepath.Base(b)
 }
 
-func formatInfo(line string) string {
-	return fmt.Sprintf("<div style='background-color:#e7f3fe;padding:10px;border-left:6px solid #2196F3;'>"+
-		"<strong>Info:</strong> %s</div>", strings.TrimSpace(line[5:]))
+// buildMarker is written into every output directory. fdl only writes into and deletes
+// directories that contain it, so a mistyped --directory can't destroy other files.
+const buildMarker = ".fdl-build"
+
+const buildMarkerContent = "This directory is generated by FastDocumentationLanguage.\n" +
+	"\"fdl build --clean\" deletes everything in it.\n"
+
+// outputDirectoryPath returns the absolute path of the output directory. A relative
+// directory is relative to the working directory.
+func outputDirectoryPath(directory string) (string, error) {
+	if directory == "" {
+		return "", errors.New("no output directory is set")
+	}
+	if filepath.IsAbs(directory) {
+		return filepath.Clean(directory), nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("can't read the working directory: %w", err)
+	}
+	return filepath.Join(cwd, directory), nil
 }
 
-func formatWarning(line string) string {
-	return fmt.Sprintf("<div style='background-color:#ffcccb;padding:10px;border-left:6px solid #f44336;'>"+
-		"<strong>Warning:</strong> %s</div>", strings.TrimSpace(line[8:]))
+// createOutputDir creates the output directory if it doesn't exist yet and returns its
+// absolute path. An existing directory is only used if it is empty or was created by fdl.
+// Files of earlier builds are kept, see removeStaleOutputs, unless clean is set.
+func createOutputDir(directory string, clean bool) (string, error) {
+	outputPath, err := outputDirectoryPath(directory)
+	if err != nil {
+		return "", err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("can't read the working directory: %w", err)
+	}
+	if rel, err := filepath.Rel(outputPath, cwd); err == nil && filepath.IsLocal(rel) {
+		return "", fmt.Errorf("the output directory %s must not contain the working directory", outputPath)
+	}
+
+	entries, err := os.ReadDir(outputPath)
+	switch {
+	case os.IsNotExist(err):
+		log.Println("The directory don't exist, it is created")
+		if err := os.MkdirAll(outputPath, 0777); err != nil {
+			return "", fmt.Errorf("can't create the output directory: %w", err)
+		}
+	case err != nil:
+		return "", fmt.Errorf("can't access the output directory: %w", err)
+	case len(entries) > 0 && !hasBuildMarker(outputPath):
+		return "", fmt.Errorf("the output directory %s is not empty and has no %s file of an earlier build, choose another directory or empty it", outputPath, buildMarker)
+	case clean:
+		if err := os.RemoveAll(outputPath); err != nil {
+			return "", fmt.Errorf("can't clean the output directory: %w", err)
+		}
+		if err := os.Mkdir(outputPath, 0777); err != nil {
+			return "", fmt.Errorf("can't create the output directory: %w", err)
+		}
+	}
+	if err := outputStream(buildMarkerContent, buildMarker, outputPath); err != nil {
+		return "", err
+	}
+	return outputPath, nil
 }
 
-func formatTip(line string) string {
-	return fmt.Sprintf("<div style='background-color:#8fbc8f;padding:10px;border-left:6px solid #6e8b3d;'>"+
-		"<strong>Tip:</strong> %s</div>", line)
+func hasBuildMarker(outputPath string) bool {
+	info, err := os.Stat(filepath.Join(outputPath, buildMarker))
+	return err == nil && info.Mode().IsRegular()
 }
 
-func processSection(line string, sections map[string]string) string {
-	sectionTitle := strings.TrimSpace(line[8:])
-	sectionID := strings.ToLower(strings.ReplaceAll(sectionTitle, " ", "-"))
-	sections[sectionID] = sectionTitle
-	return fmt.Sprintf("<h2 id='%s'>%s</h2>", sectionID, sectionTitle)
+// outputStream writes content to filename in the absolute directory outputPath. filename
+// may contain directories, which are created if needed. It may be called from several
+// goroutines at once.
+func outputStream(content string, filename string, outputPath string) error {
+	file := filepath.Join(outputPath, filepath.FromSlash(filename))
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return fmt.Errorf("can't create the output directory: %w", err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0666); err != nil {
+		return fmt.Errorf("can't write output file: %w", err)
+	}
+	return nil
 }
 
-func processDefaultLine(line string, inCodeBlock bool, inTable bool) string {
-	if inCodeBlock {
-		return fmt.Sprintf("%s\n", line)
+func creatIndex(tableofContent []string, outputPath string, renderer fdl.Renderer, m *manifest) error {
+	var chapters []fdl.Chapter
+	for index, content := range tableofContent {
+		chapterName := strings.TrimSuffix(path.Base(content), path.Ext(content))
+		folder := path.Dir(content)
+		if folder == "." {
+			folder = ""
+		}
+		chapters = append(chapters, fdl.Chapter{Number: index + 1, Name: chapterName, File: content, Folder: folder})
 	}
-	if inTable {
-		return ""
+
+	var index bytes.Buffer
+	if err := renderer.RenderIndex(&index, chapters); err != nil {
+		return fmt.Errorf("can't render the index: %w", err)
 	}
-	return line + "<br>"
+	if err := outputStream(index.String(), "index"+renderer.Extension(), outputPath); err != nil {
+		return err
+	}
+	m.addOutput(nil, "index"+renderer.Extension(), index.String())
+	return nil
 }
 
-func generateTableOfContents(sections map[string]string) string {
-	var tocBuilder strings.Builder
-	if len(sections) > 0 {
-		tocBuilder.WriteString("<h2>Table of Contents</h2><ul>")
-		for id, title := range sections {
-			tocBuilder.WriteString(fmt.Sprintf("<li><a href='#%s'>%s</a></li>", id, title))
+// processFiles converts all documents below the input directories with every enabled
+// output format. Problems are collected in the returned diagnostics instead of stopping
+// the conversion.
+func processFiles(setFlags options) *fdl.Diagnostics {
+	diags := &fdl.Diagnostics{}
+	site := fdl.Settings{Title: setFlags.SiteTitle, Theme: setFlags.Theme, Draft: setFlags.Devdoc}
+	var backends []fdl.Renderer
+	for _, format := range setFlags.Formats {
+		renderer, err := fdl.NewRenderer(format, site)
+		if err != nil {
+			diags.Errorf(fdl.Pos{}, fdl.CodeInvalidOption, "%v", err)
+			return diags
 		}
-		tocBuilder.WriteString("</ul>")
+		backends = append(backends, renderer)
+	}
+	outputPath, err := createOutputDir(setFlags.Directory, setFlags.Clean)
+	if err != nil {
+		diags.Errorf(fdl.Pos{File: setFlags.Directory}, fdl.CodeOutput, "%v", err)
+		return diags
 	}
-	return tocBuilder.String()
+	documents, err := parseDocuments(setFlags, diags)
+	if err != nil {
+		diags.Errorf(fdl.Pos{}, fdl.CodeFileSystem, "%v", err)
+		return diags
+	}
+	log.Printf("Found: %d\n", len(documents))
+	fdl.ResolveReferences(documents, diags)
+	documents = checkOutputFiles(documents, backends, setFlags.Combined, diags)
+
+	cache := &buildCache{outputPath: outputPath, rebuild: setFlags.Rebuild}
+	if cache.previous, err = readManifest(outputPath); err != nil {
+		// Without a usable cache everything is regenerated.
+		diags.Warnf(fdl.Pos{File: manifestFile}, fdl.CodeOutput, "%v", err)
+	}
+	names, err := newSourceNames(setFlags)
+	if err != nil {
+		diags.Errorf(fdl.Pos{}, fdl.CodeFileSystem, "%v", err)
+		return diags
+	}
+	m := newManifest(documents, buildSettings(setFlags), names)
+	renderDocuments(documents, backends, setFlags, outputPath, m, cache, diags)
+	cache.removeStaleOutputs(m, diags)
+	writeManifest(m, outputPath, diags)
+	return diags
 }
 
-func escapeHTML(input string) string {
-	return strings.ReplaceAll(strings.ReplaceAll(input, "<", "&lt;"), ">", "&gt;")
+// writeManifest writes the build manifest next to the generated files.
+func writeManifest(m *manifest, outputPath string, diags *fdl.Diagnostics) {
+	content, err := m.encode()
+	if err == nil {
+		err = outputStream(content, manifestFile, outputPath)
+	}
+	if err != nil {
+		diags.Errorf(fdl.Pos{File: manifestFile}, fdl.CodeOutput, "can't write the build manifest: %v", err)
+	}
 }
 
-func parseLine(line string, inCodeBlock bool, inTable bool, inList bool, isUseCaseORExample bool, sections map[string]string) (string, bool, bool, bool, bool) {
-	switch {
-	case strings.HasPrefix(line, "@title") && !inCodeBlock:
-		return fmt.Sprintf("<h1>%s</h1>", strings.TrimSpace(line[6:])), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@author") && !inCodeBlock:
-		return fmt.Sprintf("<p>Author: %s</p>", strings.TrimSpace(line[7:])), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@date") && !inCodeBlock:
-		return fmt.Sprintf("<p>Date: %s</p>", strings.TrimSpace(line[5:])), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@abstract") && !inCodeBlock:
-		return "<h2>Abstract</h2><p>", inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@info"):
-		return formatInfo(line), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@warning"):
-		return formatWarning(line), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@section") && !inCodeBlock && !isUseCaseORExample:
-		return processSection(line, sections), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@note"):
-		return fmt.Sprintf("<p><em>Note:</em> %s</p>", strings.TrimSpace(line[5:])), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@code") && !inCodeBlock:
-		if !isUseCaseORExample {
-			return "<div class='example-box'><div class='example-title'>Code:</div><div class='example-content'><pre><code>", !inCodeBlock, inTable, inList, isUseCaseORExample
-		} else {
-			return "<pre><code>", !inCodeBlock, inTable, inList, isUseCaseORExample
+// renderedFile is the result of rendering one document with one renderer.
+type renderedFile struct {
+	output manifestOutput
+	ok     bool
+	diags  fdl.Diagnostics
+}
+
+// renderDocuments writes every document with every renderer on up to setFlags.Jobs
+// workers, followed by the indexes and, if requested, the combined documentation.
+// Documents whose output in cache is still up to date are skipped. Every generated file
+// is recorded in m in the order of documents and backends, whatever order the workers
+// finish in.
+func renderDocuments(documents []*fdl.Document, backends []fdl.Renderer, setFlags options, outputPath string, m *manifest, cache *buildCache, diags *fdl.Diagnostics) {
+	results := make([]renderedFile, len(documents)*len(backends))
+	var processed atomic.Int64
+	forEach(len(results), setFlags.Jobs, func(i int) {
+		doc, renderer, result := documents[i/len(backends)], backends[i%len(backends)], &results[i]
+		defer func() {
+			log.Printf("Processed files %d / %d \n", processed.Add(1), len(results))
+		}()
+		currentFile := fdl.OutputFile(doc, renderer.Extension())
+		if output, ok := cache.reusableOutput(m, doc, currentFile); ok {
+			result.output, result.ok = output, true
+			return
 		}
-	case strings.HasPrefix(line, "@endcode") && inCodeBlock:
-		if !isUseCaseORExample {
-			return "</code></pre></div></div>", !inCodeBlock, inTable, inList, isUseCaseORExample
-		} else {
-			return "</code></pre>", !inCodeBlock, inTable, inList, isUseCaseORExample
+
+		var output bytes.Buffer
+		if err := renderer.RenderDocument(&output, doc); err != nil {
+			result.diags.Errorf(fdl.Pos{File: doc.Path}, fdl.CodeOutput, "can't render the document: %v", err)
+			return
+		}
+		if err := outputStream(output.String(), currentFile, outputPath); err != nil {
+			result.diags.Errorf(fdl.Pos{File: currentFile}, fdl.CodeOutput, "%v", err)
+			return
 		}
-	case strings.HasPrefix(line, "@tbc"):
-		return "", inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@table"):
-		return "<table border='1'>", inCodeBlock, !inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@row"):
-		cells := strings.Split(strings.TrimSpace(line[4:]), "|")
-		var rowBuilder strings.Builder
-		rowBuilder.WriteString("<tr>")
-		for _, cell := range cells {
-			rowBuilder.WriteString(fmt.Sprintf("<td>%s</td>", strings.TrimSpace(cell)))
+		result.output = manifestOutput{File: filepath.ToSlash(currentFile), Hash: hashContent(output.Bytes())}
+		result.ok = true
+	})
+	for i := range results {
+		diags.Merge(&results[i].diags)
+		if results[i].ok {
+			m.keepOutput(documents[i/len(backends)], results[i].output)
 		}
-		rowBuilder.WriteString("</tr>")
-		return rowBuilder.String(), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@endtable"):
-		return "</table>", inCodeBlock, !inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@version"):
-		return fmt.Sprintf("<p><em>Version:</em> %s</p>", strings.TrimSpace(line[8:])), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@since"):
-		return fmt.Sprintf("<p><em>Since:</em> %s</p>", strings.TrimSpace(line[6:])), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@deprecated"):
-		return "<strong><em style='color:red;'>Deprecated!</em></strong>", inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@param"):
-		params := strings.Split(strings.TrimSpace(line[6:]), "|")
-		var rowBuilder strings.Builder
-		rowBuilder.WriteString("<p><b>Parameters</b></p>")
-		for _, param := range params {
-			rowBuilder.WriteString(fmt.Sprintf("<p>%s</p>", param))
+	}
+
+	for _, renderer := range backends {
+		var mainTableOfContent []string
+		for _, doc := range documents {
+			mainTableOfContent = append(mainTableOfContent, fdl.OutputFile(doc, renderer.Extension()))
 		}
-		return rowBuilder.String(), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@return"):
-		params := strings.Split(strings.TrimSpace(line[7:]), "|")
-		var rowBuilder strings.Builder
-		rowBuilder.WriteString("<p><b>Return:</b></p>")
-		for _, param := range params {
-			rowBuilder.WriteString(fmt.Sprintf("<p>%s</p>", param))
+		if err := creatIndex(mainTableOfContent, outputPath, renderer, m); err != nil {
+			diags.Errorf(fdl.Pos{File: "index" + renderer.Extension()}, fdl.CodeOutput, "%v", err)
 		}
-		return rowBuilder.String(), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@list"):
-		line = strings.TrimSpace(line[5:])
-		switch {
-		case strings.HasPrefix(line, "-n"):
-			return "<ol>", inCodeBlock, inTable, !inList, isUseCaseORExample
-		default:
-			return "<ul>", inCodeBlock, inTable, !inList, isUseCaseORExample
+		if setFlags.Combined {
+			if err := createBundle(documents, outputPath, renderer, m); err != nil {
+				diags.Errorf(fdl.Pos{File: "documentation" + renderer.Extension()}, fdl.CodeOutput, "%v", err)
+			}
 		}
-	case strings.HasPrefix(line, "@item") && inList:
-		return fmt.Sprintf("<li>%s</li>", strings.TrimSpace(line[5:])), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@endlist"):
-		return "</ul></ol>", inCodeBlock, inTable, !inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@tip"):
-		return formatTip(strings.TrimSpace(line[4:])), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@todo"):
-		return fmt.Sprintf("<p><em>TODO:</em> %s</p>", strings.TrimSpace(line[5:])), inCodeBlock, inTable, inList, isUseCaseORExample
-	case strings.HasPrefix(line, "@example"):
-		return "<div class='example-box'><div class='example-title'>Example:</div><div class='example-content'>", inCodeBlock, inTable, inList, !isUseCaseORExample
-	case strings.HasPrefix(line, "@endexample"):
-		return "</div></div>", inCodeBlock, inTable, inList, !isUseCaseORExample
-	case strings.HasPrefix(line, "@usecase"):
-		return "<div class='example-box'><div class='example-title'>UseCase:</div><div class='example-content'>", inCodeBlock, inTable, inList, !isUseCaseORExample
-	case strings.HasPrefix(line, "@endusecase"):
-		return "</div></div>", inCodeBlock, inTable, inList, !isUseCaseORExample
-	default:
-		return processDefaultLine(line, inCodeBlock, inTable), inCodeBlock, inTable, inList, isUseCaseORExample
 	}
 }
 
-func createOrCleanOutputDir(directory string) {
-	if directory == "" {
-		log.Panic("directory is empty not set")
+// parseDocuments parses every document selected by setFlags. Documents are identified
+// by their path relative to the working directory.
+func parseDocuments(setFlags options, diags *fdl.Diagnostics) ([]*fdl.Document, error) {
+	files, err := findFiles(setFlags, true)
+	if err != nil {
+		return nil, err
 	}
-	cwd, err := os.Getwd()
+	names, err := newSourceNames(setFlags)
 	if err != nil {
-		log.Panic("Error until reading the directories: ", err)
+		return nil, err
+	}
+	cwd := names.cwd
+	var filepaths, fragments []string
+	for _, file := range files {
+		if fdl.IsFragment(file) {
+			fragments = append(fragments, file)
+		} else {
+			filepaths = append(filepaths, file)
+		}
 	}
 
-	outputPath := cwd + directory
-
-	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-		log.Println("The directory don't exist, it is created")
-		if err := os.Mkdir(outputPath, 0777); err != nil {
-			log.Panic("The System couldn't create the directory: ", err)
+	// Every file is parsed on its own, the results keep the order of filepaths.
+	parsed := make([]*fdl.Document, len(filepaths))
+	fileDiags := make([]fdl.Diagnostics, len(filepaths))
+	forEach(len(filepaths), setFlags.Jobs, func(i int) {
+		displayPath := filepaths[i]
+		if rel, err := filepath.Rel(cwd, filepaths[i]); err == nil {
+			displayPath = rel
 		}
-	} else if err != nil {
-		log.Panic("error:", err)
-	} else {
-		err := os.RemoveAll(outputPath)
+
+		doc, err := parseFile(filepaths[i], displayPath, setFlags, &fileDiags[i])
 		if err != nil {
-			log.Panic("Error until delete :", err)
-		}
-		if err := os.Mkdir(outputPath, 0777); err != nil {
+			fileDiags[i].Errorf(fdl.Pos{File: displayPath}, fdl.CodeFileSystem, "%v", err)
 			return
 		}
+		parsed[i] = doc
+	})
+
+	var documents []*fdl.Document
+	for i, doc := range parsed {
+		diags.Merge(&fileDiags[i])
+		if doc != nil {
+			doc.Name = names.name(filepaths[i])
+			documents = append(documents, doc)
+		}
 	}
+	warnUnusedFragments(fragments, documents, cwd, diags)
+	return documents, nil
+}
+
+// sourceNames names the sources in the output directory. Sources below the working
+// directory are named by their path relative to it, others relative to the input
+// directory they were found in, so a name never starts with ".." or is absolute.
+type sourceNames struct {
+	cwd    string
+	inputs []string
 }
 
-func outputStream(finaleFormattedHTML string, filename string, directory string) {
+func newSourceNames(setFlags options) (sourceNames, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
-		log.Panic("Error until reading the directories: ", err)
-	}
-
-	outputPath := cwd + directory
-
-	if err := os.Chdir(outputPath); err != nil {
-		fmt.Println(cwd)
-		log.Panic("Can't change directory: ", err)
+		return sourceNames{}, fmt.Errorf("can't read the working directory: %w", err)
 	}
-
-	outputFile, err := os.Create(filename)
-	if err != nil {
-		log.Panic("Can't write in output file: ", err)
+	names := sourceNames{cwd: cwd}
+	for _, input := range setFlags.Inputs {
+		if !filepath.IsAbs(input) {
+			input = filepath.Join(cwd, input)
+		}
+		names.inputs = append(names.inputs, input)
 	}
+	return names, nil
+}
 
-	_, _ = outputFile.WriteString(finaleFormattedHTML)
-	if err != nil {
-		return
+// name returns the name of the source at path, which is absolute or relative to the
+// working directory, with "/" as separator.
+func (n sourceNames) name(path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(n.cwd, path)
 	}
-	outputFile.Close()
-	if err := os.Chdir(cwd); err != nil {
-		fmt.Println(cwd)
-		log.Panic("Can't change directory: ", err)
+	for _, dir := range append([]string{n.cwd}, n.inputs...) {
+		if rel, err := filepath.Rel(dir, path); err == nil && filepath.IsLocal(rel) {
+			return filepath.ToSlash(rel)
+		}
 	}
+	return filepath.Base(path)
 }
 
-func creatIndex(tableofContent []string, directory string) {
-	table := "<html><body><h1>Documentation <br> Table Of Content</h1><ul>"
-	for index, content := range tableofContent {
-		chapterName := strings.Split(content, ".")
-		chapterNumber := index + 1
-
-		chapterFullName := strconv.Itoa(chapterNumber) + " " + chapterName[0]
-		table = table + "<li> <a href='" + content + "'>" + chapterFullName + "</a></li>"
+// checkOutputFiles reports the documents whose output file is already taken by an earlier
+// document, the index or the combined documentation, and leaves them out of the build, so
+// no file is written twice.
+func checkOutputFiles(documents []*fdl.Document, backends []fdl.Renderer, combined bool, diags *fdl.Diagnostics) []*fdl.Document {
+	owners := make(map[string]string)
+	for _, renderer := range backends {
+		owners["index"+renderer.Extension()] = "the index"
+		if combined {
+			owners["documentation"+renderer.Extension()] = "the combined documentation"
+		}
 	}
-	table = table + "</ul></body></html>"
-
-	outputStream(table, "index.html", directory)
-
-}
-func processStyling() string {
-	return "<style>.example-box {border: 2px solid black;padding: 10px;margin: 20px 0;" +
-		"border-radius: 5px;background-color: #f9f9f9;position: relative;overflow: hidden;}" +
-		".example-title {font-weight: bold;margin: 0;padding: 5px 10px;background-color: #e0e0e0;" +
-		"border-bottom: 2px solid black;position: absolute;top: 0;left: 0;width: 100%;box-sizing: border-box;}" +
-		".example-content {padding-top: 40px;}</style>"
-}
-func processFiles() {
-	var mainTableOfContent []string
-	setFlags := getFlagsFromCli()
-	createOrCleanOutputDir(setFlags.Directory)
-	filepaths := getFilePath(setFlags.FileExtension)
-	lengthFilepaths := len(filepaths)
-	log.Printf("Found: %d\n", lengthFilepaths)
-	var processedFileCounter int = 0
-	for _, path := range filepaths {
-		processedFileCounter += 1
-		log.Printf("Processed files %d / %d \n", processedFileCounter, lengthFilepaths)
-		var output strings.Builder
-		sections := make(map[string]string)
-		inCodeBlock := false
-		inTable := false
-		inList := false
-		isUsecaseOrExample := false
-		mainTableOfContent = append(mainTableOfContent, convertFileNameToHTMLFile(filepath.Base(path)))
-		currentFile := mainTableOfContent[len(mainTableOfContent)-1]
-		fdlFile, err := os.Open(path)
-		if err != nil {
-			log.Panic(err)
-			return
+	var kept []*fdl.Document
+	for _, doc := range documents {
+		conflict := false
+		for _, renderer := range backends {
+			file := fdl.OutputFile(doc, renderer.Extension())
+			if owner, ok := owners[file]; ok {
+				diags.Errorf(fdl.Pos{File: doc.Path}, fdl.CodeOutputConflict, "the output file %s is already written for %s", file, owner)
+				conflict = true
+				break
+			}
 		}
+		if conflict {
+			continue
+		}
+		for _, renderer := range backends {
+			owners[fdl.OutputFile(doc, renderer.Extension())] = filepath.ToSlash(doc.Path)
+		}
+		kept = append(kept, doc)
+	}
+	return kept
+}
 
-		scanner := bufio.NewScanner(fdlFile)
-		for scanner.Scan() {
-			line := scanner.Text()
-			line, inCodeBlock, inTable, inList, isUsecaseOrExample = parseLine(escapeHTML(line), inCodeBlock, inTable, inList, isUsecaseOrExample, sections)
-			if line != "" {
-				output.WriteString(line)
-				output.WriteString("\n")
+// warnUnusedFragments reports the fragments that no document includes. They are not
+// converted on their own, which is likely a mistake for a document whose name happens
+// to start with "_".
+func warnUnusedFragments(fragments []string, documents []*fdl.Document, cwd string, diags *fdl.Diagnostics) {
+	included := make(map[string]bool)
+	for _, doc := range documents {
+		for file := range doc.Includes {
+			if file = filepath.FromSlash(file); !filepath.IsAbs(file) {
+				file = filepath.Join(cwd, file)
 			}
+			included[file] = true
 		}
-
-		if err := scanner.Err(); err != nil {
-			log.Panic("Error reading file:", err)
+	}
+	for _, fragment := range fragments {
+		if included[fragment] {
+			continue
 		}
+		displayPath := fragment
+		if rel, err := filepath.Rel(cwd, fragment); err == nil {
+			displayPath = rel
+		}
+		diags.Warnf(fdl.Pos{File: displayPath}, fdl.CodeUnusedFragment,
+			"%s is not converted because its name starts with \"_\", but no document includes it", filepath.Base(fragment))
+	}
+}
 
-		toc := generateTableOfContents(sections)
-		finalOutput := strings.Replace(output.String(), "<h1>", "<h1>"+toc+"\n", 1)
-		finalOutput = finalOutput + processStyling()
+// parseFile parses the document at path. displayPath is used in source positions.
+func parseFile(path string, displayPath string, setFlags options, diags *fdl.Diagnostics) (*fdl.Document, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return fdl.Parse(file, setFlags.documentOptions(displayPath, diags))
+}
 
-		outputStream(finalOutput, currentFile, setFlags.Directory)
-		fdlFile.Close()
+// createBundle writes all documents into one file, ordered like the index.
+func createBundle(documents []*fdl.Document, outputPath string, renderer fdl.Renderer, m *manifest) error {
+	b, ok := renderer.(fdl.Bundler)
+	if !ok {
+		log.Println("The selected output format can't combine the documents into one file.")
+		return nil
 	}
 
-	creatIndex(mainTableOfContent, setFlags.Directory)
+	var bundle bytes.Buffer
+	if err := b.RenderBundle(&bundle, documents); err != nil {
+		return fmt.Errorf("can't render the combined documentation: %w", err)
+	}
+	if err := outputStream(bundle.String(), "documentation"+renderer.Extension(), outputPath); err != nil {
+		return err
+	}
+	m.addOutput(nil, "documentation"+renderer.Extension(), bundle.String())
+	return nil
 }
 
 func createAsciiBanner() {
@@ -355,6 +507,5 @@ func createAsciiBanner() {
 }
 
 func main() {
-	createAsciiBanner()
-	processFiles()
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"FastDocumentationLanguage/fdl"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// manifestFile is the name of the build manifest in the output directory.
+const manifestFile = "manifest.json"
+
+// manifest describes a build: which sources were converted by which version of FDL and
+// which files were written. It contains no timestamps or absolute paths, so identical
+// sources produce an identical manifest on every machine. Sources are recorded by their
+// name, see sourceNames.
+type manifest struct {
+	FDLVersion string `json:"fdlVersion"`
+	// Settings is a hash of the build options that affect the output, see buildSettings.
+	Settings  string             `json:"settings"`
+	Documents []manifestDocument `json:"documents"`
+	// Files are the outputs that belong to no single document, like the index.
+	Files []manifestOutput `json:"files"`
+}
+
+type manifestDocument struct {
+	Input string `json:"input"`
+	Hash  string `json:"hash"`
+	// Dependencies maps the documents this one depends on to their hash, "" if they
+	// don't exist.
+	Dependencies map[string]string `json:"dependencies"`
+	Outputs      []manifestOutput  `json:"outputs"`
+}
+
+type manifestOutput struct {
+	File string `json:"file"`
+	Hash string `json:"hash"`
+}
+
+func newManifest(documents []*fdl.Document, settings string, names sourceNames) *manifest {
+	m := &manifest{FDLVersion: version, Settings: settings, Documents: []manifestDocument{}, Files: []manifestOutput{}}
+	hashes := make(map[string]string, len(documents))
+	for _, doc := range documents {
+		hashes[filepath.ToSlash(filepath.Clean(doc.Path))] = dependencyHash(doc)
+	}
+	for _, doc := range documents {
+		dependencies := make(map[string]string, len(doc.Dependencies))
+		for _, dependency := range doc.Dependencies {
+			dependencies[names.name(filepath.FromSlash(dependency))] = hashes[dependency]
+		}
+		for include, hash := range doc.Includes {
+			dependencies[names.name(filepath.FromSlash(include))] = hash
+		}
+		m.Documents = append(m.Documents, manifestDocument{Input: doc.Name, Hash: doc.Hash,
+			Dependencies: dependencies, Outputs: []manifestOutput{}})
+	}
+	sort.Slice(m.Documents, func(i, j int) bool { return m.Documents[i].Input < m.Documents[j].Input })
+	return m
+}
+
+// dependencyHash returns the hash of everything other documents take from doc, e.g. the
+// titles of its sections: its source and the fragments it includes.
+func dependencyHash(doc *fdl.Document) string {
+	if len(doc.Includes) == 0 {
+		return doc.Hash
+	}
+	includes := make([]string, 0, len(doc.Includes))
+	for include, hash := range doc.Includes {
+		includes = append(includes, include+"="+hash)
+	}
+	sort.Strings(includes)
+	return hashContent([]byte(doc.Hash + "\n" + strings.Join(includes, "\n")))
+}
+
+// addOutput records that file was written from doc. doc is nil for files that belong to
+// no single document.
+func (m *manifest) addOutput(doc *fdl.Document, file string, content string) {
+	m.keepOutput(doc, manifestOutput{File: filepath.ToSlash(file), Hash: hashContent([]byte(content))})
+}
+
+// keepOutput records an output that is already in the output directory.
+func (m *manifest) keepOutput(doc *fdl.Document, output manifestOutput) {
+	if doc == nil {
+		m.Files = append(m.Files, output)
+		return
+	}
+	if current := m.document(doc.Name); current != nil {
+		current.Outputs = append(current.Outputs, output)
+	}
+}
+
+func (m *manifest) encode() (string, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(m); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// hashContent returns the SHA-256 of content as "sha256:<hex>".
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+	source := "@title Guide\n@section Setup\nText\n"
+	if err := os.MkdirAll("docs", 0755); err != nil {
+		t.Fatalf("Could not create test directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join("docs", "guide.fdl"), []byte(source), 0644); err != nil {
+		t.Fatalf("Could not create test file: %v", err)
+	}
+
+	opts := defaultOptions()
+	opts.Formats = []string{"html", "pdf"}
+	opts.Combined = true
+	var builds [][]byte
+	for i := 0; i < 2; i++ {
+		if diags := processFiles(opts); diags.HasErrors() {
+			t.Fatalf("Expected no errors, got %v", diags.Sorted())
+		}
+		content, err := os.ReadFile(filepath.Join("documentation", manifestFile))
+		if err != nil {
+			t.Fatalf("Failed to read the manifest: %v", err)
+		}
+		builds = append(builds, content)
+	}
+	if !bytes.Equal(builds[0], builds[1]) {
+		t.Errorf("Expected identical manifests, got\n%s\nand\n%s", builds[0], builds[1])
+	}
+
+	var m manifest
+	if err := json.Unmarshal(builds[0], &m); err != nil {
+		t.Fatalf("Failed to decode the manifest: %v", err)
+	}
+	if m.FDLVersion != version {
+		t.Errorf("Expected version %s, got %s", version, m.FDLVersion)
+	}
+	if len(m.Documents) != 1 || m.Documents[0].Input != "docs/guide.fdl" || m.Documents[0].Hash != hashContent([]byte(source)) {
+		t.Fatalf("Expected docs/guide.fdl with the hash of its source, got %+v", m.Documents)
+	}
+	// Jede Ausgabedatei muss mit dem Hash im Manifest übereinstimmen.
+	outputs := append(m.Documents[0].Outputs, m.Files...)
+	if len(outputs) != 5 {
+		t.Errorf("Expected guide, index and bundle outputs, got %+v", outputs)
+	}
+	for _, output := range outputs {
+		content, err := os.ReadFile(filepath.Join("documentation", output.File))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", output.File, err)
+		}
+		if hashContent(content) != output.Hash {
+			t.Errorf("Expected %s to have hash %s", output.File, output.Hash)
+		}
+	}
+}
+
+func TestManifestNamesSourcesOutsideTheWorkingDirectory(t *testing.T) {
+	external := t.TempDir()
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		filepath.Join(external, "a", "intro.fdl"):    "@title A\n@include _contact.fdl\n@ref ../b/setup.fdl",
+		filepath.Join(external, "a", "_contact.fdl"): "Contact",
+		filepath.Join(external, "b", "setup.fdl"):    "@title Setup",
+	})
+	opts := defaultOptions()
+	opts.Inputs = []string{external}
+	if diags := processFiles(opts); diags.HasErrors() {
+		t.Fatalf("Expected no errors, got %v", diags.Sorted())
+	}
+
+	content, err := os.ReadFile(filepath.Join("documentation", manifestFile))
+	if err != nil {
+		t.Fatalf("Expected a manifest: %v", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		t.Fatalf("Expected valid JSON, got %s: %v", content, err)
+	}
+	// Quellen außerhalb des Arbeitsverzeichnisses stehen relativ zu ihrem Eingabeordner im Manifest.
+	if len(m.Documents) != 2 || m.Documents[0].Input != "a/intro.fdl" || m.Documents[1].Input != "b/setup.fdl" {
+		t.Fatalf("Expected a/intro.fdl and b/setup.fdl, got %+v", m.Documents)
+	}
+	for _, dependency := range []string{"a/_contact.fdl", "b/setup.fdl"} {
+		if _, ok := m.Documents[0].Dependencies[dependency]; !ok {
+			t.Errorf("Expected the dependency %s, got %v", dependency, m.Documents[0].Dependencies)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"FastDocumentationLanguage/fdl"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// stdinPath is the name of a document read from standard input in diagnostics.
+const stdinPath = "<stdin>"
+
+// stdin is read by "fdl render -". It is a variable so tests can replace it.
+var stdin io.Reader = os.Stdin
+
+// runRender converts a single document without searching for documents and without
+// an output directory, e.g. "fdl render - < doc.fdl > doc.html" in a pipe or
+// "fdl render docs/guide.fdl -o site/guide.html" in a Makefile rule.
+func runRender(args []string, stdout io.Writer, stderr io.Writer) int {
+	opts := defaultOptions()
+	if _, err := loadConfig(&opts); err != nil {
+		return usageError(err, "render", stderr)
+	}
+	format, output := "", "-"
+	fs := newFlagSet("render", "render [options] <file | ->", stderr)
+	stringFlag(fs, &output, "output", "o", `output file, "-" for standard output. Links to other documents assume the output mirrors the source folders`)
+	stringFlag(fs, &format, "format", "fmt", "output format: "+strings.Join(fdl.RendererNames(), ", ")+", default by the extension of --output, otherwise html")
+	boolFlag(fs, &opts.Numbered, "number-sections", "", "number sections and subsections, e.g. 2.1.3")
+	boolFlag(fs, &opts.Devdoc, "development-documentation", "dev-doc", "include @todo, @tbc and @internal content and mark the output as draft")
+	stringFlag(fs, &opts.Theme, "theme", "", "HTML theme: "+strings.Join(fdl.ThemeNames(), ", "))
+	stringFlag(fs, &opts.SiteTitle, "title", "", "title of the documentation")
+	fs.Var(varsValue{&opts.Vars}, "var", "set a variable for @if conditions, e.g. --var audience=internal (repeatable)")
+	listFlag(fs, &opts.Tags, "tag", "", "comma separated tags for @if conditions")
+	inputs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageError(err, "render", stderr)
+	}
+	if len(inputs) != 1 {
+		return usageError(errors.New(`expected one document, or "-" for standard input`), "render", stderr)
+	}
+	if output == "" {
+		return usageError(errors.New("--output must not be empty"), "render", stderr)
+	}
+	if format == "" {
+		format = formatForFile(output)
+	}
+	if !slices.Contains(fdl.ThemeNames(), opts.Theme) {
+		return usageError(fmt.Errorf("unknown theme %q (available: %s)", opts.Theme, strings.Join(fdl.ThemeNames(), ", ")), "render", stderr)
+	}
+	renderer, err := fdl.NewRenderer(format, fdl.Settings{Title: opts.SiteTitle, Theme: opts.Theme, Draft: opts.Devdoc})
+	if err != nil {
+		return usageError(err, "render", stderr)
+	}
+
+	diags := &fdl.Diagnostics{}
+	var content bytes.Buffer
+	if err := renderSingle(&content, inputs[0], renderer, opts, diags); err != nil {
+		diags.Errorf(fdl.Pos{File: inputs[0]}, fdl.CodeFileSystem, "%v", err)
+	}
+	// Nothing is written if the document has errors, so a Makefile rule doesn't leave
+	// an incomplete file behind that looks up to date.
+	if !diags.HasErrors() {
+		if err := writeRendered(content.Bytes(), output, stdout); err != nil {
+			diags.Errorf(fdl.Pos{File: output}, fdl.CodeOutput, "%v", err)
+		}
+	}
+	return reportDiagnostics(diags, stderr)
+}
+
+// parseInterspersed parses args into fs like fs.Parse, but also accepts options after
+// the positional arguments, e.g. "render doc.fdl -o doc.html". It returns the positional
+// arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := parseArgs(fs, args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// formatForFile returns the output format whose extension the file has, html if there is none.
+func formatForFile(file string) string {
+	for _, name := range fdl.RendererNames() {
+		if renderer, err := fdl.NewRenderer(name, fdl.Settings{}); err == nil && renderer.Extension() == filepath.Ext(file) {
+			return name
+		}
+	}
+	return "html"
+}
+
+// renderSingle renders the document at input, or standard input for "-", to w.
+// References to other documents are resolved by reading their files, without rendering them.
+// Their links are relative to the source, as if every document is rendered to the place
+// of its source in the output, like "fdl build" does; the output file itself is unknown.
+func renderSingle(w io.Writer, input string, renderer fdl.Renderer, setFlags options, diags *fdl.Diagnostics) error {
+	source := stdin
+	displayPath := stdinPath
+	if input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		source, displayPath = file, relativeToWorkingDirectory(input)
+	}
+
+	doc, err := fdl.Convert(source, setFlags.documentOptions(displayPath, diags))
+	if diags.HasErrors() {
+		// The errors are reported with the other diagnostics.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return renderer.RenderDocument(w, doc)
+}
+
+// relativeToWorkingDirectory returns path relative to the working directory if it is
+// below it, otherwise path itself.
+func relativeToWorkingDirectory(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, abs); err == nil && filepath.IsLocal(rel) {
+		return rel
+	}
+	return path
+}
+
+// writeRendered writes content to the file output, or to stdout for "-". Missing
+// directories of output are created.
+func writeRendered(content []byte, output string, stdout io.Writer) error {
+	if output == "-" {
+		_, err := stdout.Write(content)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0777); err != nil {
+		return fmt.Errorf("can't create the directory of %s: %w", output, err)
+	}
+	if err := os.WriteFile(output, content, 0666); err != nil {
+		return fmt.Errorf("can't write %s: %w", output, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRenderStdin(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"install.fdl": "@title Installation\n@section Configuration\nSet it up."})
+	stdin = strings.NewReader("@title Guide\n@section Start\n@ref install.fdl#configuration")
+	defer func() { stdin = os.Stdin }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"render", "--number-sections", "-"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	for _, expected := range []string{"Guide</h1>", ">1 Start</h2>", "<a href='install.html#configuration'>1 Configuration</a>"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected the output to contain %q, got:\n%s", expected, stdout.String())
+		}
+	}
+	// Ohne Ausgabeverzeichnis und ohne Manifest.
+	for _, name := range []string{"documentation", "install.html"} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be created, got %v", name, err)
+		}
+	}
+}
+
+func TestRenderFile(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"docs/guide.fdl":  "@title Guide\n@section Start\nHello",
+		"docs/broken.fdl": "@title Broken\n@ref missing.fdl",
+	})
+
+	// Optionen nach der Datei, das Format folgt aus der Endung.
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"render", "docs/guide.fdl", "-o", "out/guide.md"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	content, err := os.ReadFile("out/guide.md")
+	if err != nil {
+		t.Fatalf("Expected out/guide.md to be written: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "# Guide") || stdout.Len() > 0 {
+		t.Errorf("Expected Markdown in out/guide.md and nothing on stdout, got %q and %q", content, stdout.String())
+	}
+
+	// Bei Fehlern wird keine Datei geschrieben.
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"render", "-o", "out/broken.html", "docs/broken.fdl"}, &stdout, &stderr); code != exitFindings {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "docs/broken.fdl:2:1: error[FDL116]") {
+		t.Errorf("Expected the dangling reference to be reported, got %s", stderr.String())
+	}
+	if _, err := os.Stat("out/broken.html"); !os.IsNotExist(err) {
+		t.Errorf("Expected out/broken.html not to be written, got %v", err)
+	}
+
+	tests := [][]string{
+		{"render"},
+		{"render", "docs/guide.fdl", "docs/broken.fdl"},
+		{"render", "--format=docx", "docs/guide.fdl"},
+	}
+	for _, args := range tests {
+		if code := run(args, &stdout, &stderr); code != exitUsage {
+			t.Errorf("Expected exit code 2 for %v, got %d", args, code)
+		}
+	}
+}
+
+func TestRenderLinksFollowTheSources(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"docs/api/intro.fdl":   "@title API\n@ref ../guide/intro.fdl#setup",
+		"docs/guide/intro.fdl": "@title Guide\n@section Setup\nHello",
+	})
+
+	// Die Links folgen den Quelldateien, nicht dem Namen der Ausgabedatei.
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"render", "docs/api/intro.fdl", "-o", "out/a.md"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	content, err := os.ReadFile("out/a.md")
+	if err != nil {
+		t.Fatalf("Expected out/a.md to be written: %v", err)
+	}
+	if expected := "](../guide/intro.md#setup)"; !strings.Contains(string(content), expected) {
+		t.Errorf("Expected %q in out/a.md, got:\n%s", expected, content)
+	}
+}
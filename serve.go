@@ -0,0 +1,170 @@
+package main
+
+import (
+	"FastDocumentationLanguage/fdl"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// reloadPath is the server-sent events endpoint the live reload script listens to.
+const reloadPath = "/__fdl/events"
+
+// previewServer serves the output directory of "fdl serve". Every HTML page gets a small
+// script that reloads it after the next build. If the last build had errors, they are
+// shown in an overlay on top of the page.
+type previewServer struct {
+	outputPath string
+	files      http.Handler
+
+	mu sync.Mutex
+	// generation counts the builds, the script reloads the page once it changes.
+	generation int
+	// errors are the diagnostics of the last build if it had errors.
+	errors []fdl.Diagnostic
+	// built is closed after the next build.
+	built chan struct{}
+}
+
+func newPreviewServer(outputPath string) *previewServer {
+	return &previewServer{outputPath: outputPath, files: http.FileServer(http.Dir(outputPath)), built: make(chan struct{})}
+}
+
+// update publishes the result of a build to the browsers.
+func (s *previewServer) update(diags *fdl.Diagnostics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+	s.errors = nil
+	if diags.HasErrors() {
+		s.errors = diags.Sorted()
+	}
+	close(s.built)
+	s.built = make(chan struct{})
+}
+
+func (s *previewServer) state() (int, []fdl.Diagnostic, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation, s.errors, s.built
+}
+
+func (s *previewServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == reloadPath {
+		s.serveEvents(w, r)
+		return
+	}
+
+	name := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+	if path.Ext(name) != ".html" {
+		s.files.ServeHTTP(w, r)
+		return
+	}
+
+	generation, errs, _ := s.state()
+	page, err := os.ReadFile(filepath.Join(s.outputPath, filepath.FromSlash(name)))
+	if errors.Is(err, os.ErrNotExist) && errs == nil {
+		http.NotFound(w, r)
+		return
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if page == nil {
+		// The page was never built, so only the errors can be shown.
+		page = []byte("<html><head><title>Build failed</title></head><body></body></html>")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = io.WriteString(w, injectPreview(string(page), generation, errs))
+}
+
+// serveEvents sends the build generation to the live reload script, first when it
+// connects and then after every build, until the browser goes away.
+func (s *previewServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	for {
+		generation, _, built := s.state()
+		fmt.Fprintf(w, "data: %d\n\n", generation)
+		flusher.Flush()
+		select {
+		case <-built:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// injectPreview adds the live reload script and, if there are errors, the error overlay
+// to page. generation is the build the page belongs to.
+func injectPreview(page string, generation int, errs []fdl.Diagnostic) string {
+	var b strings.Builder
+	if len(errs) > 0 {
+		b.WriteString("<div id='fdl-errors' style='position:fixed;top:0;left:0;right:0;bottom:0;overflow:auto;z-index:2147483647;" +
+			"background:rgba(20,20,20,0.92);color:#fff;padding:24px;font-family:monospace;'>")
+		b.WriteString("<h2 style='color:#ff6b6b;'>Build failed</h2><p>The page is reloaded once the errors are fixed.</p><pre>")
+		for _, d := range errs {
+			b.WriteString(html.EscapeString(d.String()) + "\n")
+		}
+		b.WriteString("</pre></div>")
+	}
+	fmt.Fprintf(&b, "<script>(function(){var generation='%d';new EventSource('%s').onmessage=function(e){"+
+		"if(e.data!==generation){location.reload();}};})();</script>", generation, reloadPath)
+
+	if i := strings.LastIndex(page, "</body>"); i >= 0 {
+		return page[:i] + b.String() + page[i:]
+	}
+	return page + b.String()
+}
+
+func runServe(args []string, stdout io.Writer, stderr io.Writer) int {
+	addr := "localhost:8080"
+	w, opts, err := newWatcher("serve", args, stdout, stderr, func(fs *flag.FlagSet) {
+		stringFlag(fs, &addr, "addr", "", "address the HTTP server listens on")
+	})
+	if err != nil {
+		return usageError(err, "serve", stderr)
+	}
+	outputPath, err := outputDirectoryPath(opts.Directory)
+	if err != nil {
+		fmt.Fprintf(stderr, "fdl serve: %v\n", err)
+		return exitFindings
+	}
+
+	server := newPreviewServer(outputPath)
+	w.onBuild = server.update
+	// Build errors don't stop the server, they are shown in the browser until they are fixed.
+	last := w.build(nil)
+	stop := interrupted()
+	go w.watch(last, stop)
+
+	httpServer := &http.Server{Addr: addr, Handler: server}
+	go func() {
+		<-stop
+		_ = httpServer.Close()
+	}()
+	fmt.Fprintf(stdout, "Serving the documentation on http://%s/\n", addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "fdl serve: %v\n", err)
+		return exitFindings
+	}
+	return exitOK
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"FastDocumentationLanguage/fdl"
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInjectPreview(t *testing.T) {
+	page := "<html><body><p>Text</p></body></html>"
+	result := injectPreview(page, 3, nil)
+	if !strings.HasPrefix(result, "<html><body><p>Text</p><script>") || !strings.HasSuffix(result, "</script></body></html>") {
+		t.Errorf("Expected the script before </body>, got %s", result)
+	}
+	if !strings.Contains(result, "var generation='3'") || strings.Contains(result, "fdl-errors") {
+		t.Errorf("Expected generation 3 and no overlay, got %s", result)
+	}
+
+	errs := []fdl.Diagnostic{{Pos: fdl.Pos{File: "guide.fdl", Line: 2, Column: 1}, Severity: fdl.SeverityError, Code: fdl.CodeDanglingReference, Message: "@ref to unknown document <x>.fdl"}}
+	result = injectPreview("<p>No body</p>", 1, errs)
+	if !strings.HasPrefix(result, "<p>No body</p><div id='fdl-errors'") {
+		t.Errorf("Expected the overlay after the page, got %s", result)
+	}
+	if !strings.Contains(result, "guide.fdl:2:1: error[FDL116]: @ref to unknown document &lt;x&gt;.fdl") {
+		t.Errorf("Expected the escaped error in the overlay, got %s", result)
+	}
+}
+
+func TestPreviewServer(t *testing.T) {
+	outputPath := t.TempDir()
+	for name, content := range map[string]string{
+		"index.html": "<html><body>Index</body></html>",
+		"guide.md":   "# Guide",
+	} {
+		if err := os.WriteFile(filepath.Join(outputPath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Could not create %s: %v", name, err)
+		}
+	}
+	server := newPreviewServer(outputPath)
+	get := func(target string) (int, string) {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder.Code, recorder.Body.String()
+	}
+
+	tests := []struct {
+		target   string
+		errors   bool
+		status   int
+		contains string
+		missing  string
+	}{
+		{"/", false, http.StatusOK, "Index<script>", "fdl-errors"},
+		{"/index.html", false, http.StatusOK, "EventSource('/__fdl/events')", "fdl-errors"},
+		{"/guide.md", false, http.StatusOK, "# Guide", "<script>"},
+		{"/missing.html", false, http.StatusNotFound, "not found", "<script>"},
+		{"/", true, http.StatusOK, "Index<div id='fdl-errors'", ""},
+		{"/missing.html", true, http.StatusInternalServerError, "unknown directive", ""},
+	}
+	for _, tt := range tests {
+		diags := &fdl.Diagnostics{}
+		if tt.errors {
+			diags.Errorf(fdl.Pos{File: "guide.fdl", Line: 1, Column: 1}, fdl.CodeUnknownDirective, "unknown directive @bogus")
+		}
+		server.update(diags)
+
+		status, body := get(tt.target)
+		if status != tt.status {
+			t.Errorf("%s (errors=%t): Expected status %d, got %d", tt.target, tt.errors, tt.status, status)
+		}
+		if !strings.Contains(body, tt.contains) || (tt.missing != "" && strings.Contains(body, tt.missing)) {
+			t.Errorf("%s (errors=%t): Expected %q and not %q, got %s", tt.target, tt.errors, tt.contains, tt.missing, body)
+		}
+	}
+}
+
+func TestReloadEvents(t *testing.T) {
+	server := newPreviewServer(t.TempDir())
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	response, err := http.Get(httpServer.URL + reloadPath)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %s", contentType)
+	}
+
+	reader := bufio.NewReader(response.Body)
+	readEvent := func() string {
+		t.Helper()
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		_, _ = reader.ReadString('\n')
+		return strings.TrimSpace(line)
+	}
+	if event := readEvent(); event != "data: 0" {
+		t.Errorf("Expected the current generation, got %q", event)
+	}
+	server.update(&fdl.Diagnostics{})
+	if event := readEvent(); event != "data: 1" {
+		t.Errorf("Expected the next generation after a build, got %q", event)
+	}
+}
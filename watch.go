@@ -0,0 +1,251 @@
+package main
+
+import (
+	"FastDocumentationLanguage/fdl"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// fileState is what the watcher knows about a file. A file counts as changed if its
+// size or modification time differ.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshot is the state of all watched files, keyed by path.
+type snapshot map[string]fileState
+
+// takeSnapshot records the state of the documents and fragments selected by opts and of
+// the configuration file.
+func takeSnapshot(opts options) (snapshot, error) {
+	paths, err := findFiles(opts, true)
+	if err != nil {
+		return nil, err
+	}
+	paths = append(paths, configFiles...)
+
+	s := make(snapshot, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		s[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+	}
+	return s, nil
+}
+
+// changes returns the sorted paths that were added, modified or removed since previous.
+func (s snapshot) changes(previous snapshot) []string {
+	var changed []string
+	for path, state := range s {
+		if before, ok := previous[path]; !ok || before.size != state.size || !before.modTime.Equal(state.modTime) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range previous {
+		if _, ok := s[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// watcher rebuilds the documentation whenever a document or the configuration changes.
+// Files are polled, which works the same on every platform and file system.
+type watcher struct {
+	// load reads the options again before every build, so changes of the
+	// configuration file take effect.
+	load func() (options, error)
+	// interval is the time between two polls.
+	interval time.Duration
+	// debounce is how long the files must stay unchanged before a build starts, so
+	// saving several files at once results in a single build.
+	debounce time.Duration
+	stdout   io.Writer
+	stderr   io.Writer
+	// command is the name of the command messages are prefixed with.
+	command string
+	// onBuild is called with the result of every build, if set.
+	onBuild func(diags *fdl.Diagnostics)
+}
+
+// run builds the documentation and then rebuilds it after every change until stop is closed.
+func (w *watcher) run(stop <-chan struct{}) {
+	w.watch(w.build(nil), stop)
+}
+
+// watch rebuilds the documentation after every change of the files recorded in last
+// until stop is closed.
+func (w *watcher) watch(last snapshot, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(w.interval):
+		}
+		current := w.snapshot()
+		if len(current.changes(last)) == 0 {
+			continue
+		}
+
+		// Wait until the files stop changing.
+		for stable := false; !stable; {
+			select {
+			case <-stop:
+				return
+			case <-time.After(w.debounce):
+			}
+			next := w.snapshot()
+			stable = len(next.changes(current)) == 0
+			current = next
+		}
+		last = w.build(current.changes(last))
+	}
+}
+
+// build runs a build and returns the state of the files it was started with. Builds
+// skip all files that are still up to date, so only the changed documents and the
+// documents that depend on them are rendered again.
+func (w *watcher) build(changed []string) snapshot {
+	for _, path := range changed {
+		fmt.Fprintf(w.stdout, "changed: %s\n", displayPath(path))
+	}
+	current := w.snapshot()
+	diags := &fdl.Diagnostics{}
+	opts, err := w.load()
+	if err != nil {
+		fmt.Fprintf(w.stderr, "fdl %s: %v\n", w.command, err)
+		diags.Errorf(fdl.Pos{}, fdl.CodeInvalidOption, "%v", err)
+	} else if diags = processFiles(opts); diags.Len() > 0 {
+		diags.Print(w.stderr)
+	} else {
+		fmt.Fprintf(w.stdout, "build finished without problems\n")
+	}
+	if w.onBuild != nil {
+		w.onBuild(diags)
+	}
+	fmt.Fprintln(w.stdout, "Watching for changes, press Ctrl+C to stop.")
+	return current
+}
+
+// snapshot takes a snapshot with the current options. If they can't be read, e.g. while
+// the configuration file is being edited, the default options are used.
+func (w *watcher) snapshot() snapshot {
+	opts, err := w.load()
+	if err != nil {
+		opts = defaultOptions()
+	}
+	s, err := takeSnapshot(opts)
+	if err != nil {
+		return snapshot{}
+	}
+	return s
+}
+
+// displayPath returns path relative to the working directory if possible.
+func displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// newWatcher parses the options of command, which may add its own flags with extra,
+// and returns a watcher that reads them again before every build. The flags of extra are
+// only read once: the options are read again in the goroutine that watches the files,
+// while the command keeps using the values it started with, e.g. the address of
+// "fdl serve".
+func newWatcher(command string, args []string, stdout io.Writer, stderr io.Writer, extra func(fs *flag.FlagSet)) (*watcher, options, error) {
+	w := &watcher{interval: 500 * time.Millisecond, debounce: 200 * time.Millisecond, stdout: stdout, stderr: stderr, command: command}
+	flags := func(fs *flag.FlagSet) {
+		fs.DurationVar(&w.interval, "interval", w.interval, "time between two checks for changed files")
+		fs.DurationVar(&w.debounce, "debounce", w.debounce, "time files must stay unchanged before a build starts")
+	}
+	var extraFlags []*flag.Flag
+	opts, err := parseOptions(command, args, stderr, func(fs *flag.FlagSet) {
+		flags(fs)
+		if extra == nil {
+			return
+		}
+		known := make(map[string]bool)
+		fs.VisitAll(func(f *flag.Flag) { known[f.Name] = true })
+		extra(fs)
+		fs.VisitAll(func(f *flag.Flag) {
+			if !known[f.Name] {
+				extraFlags = append(extraFlags, f)
+			}
+		})
+	})
+	if err != nil {
+		return nil, opts, err
+	}
+	w.load = func() (options, error) {
+		return parseOptions(command, args, io.Discard, func(fs *flag.FlagSet) {
+			flags(fs)
+			for _, f := range extraFlags {
+				fs.Var(discardValue{isBool: isBoolFlag(f.Value)}, f.Name, f.Usage)
+			}
+		})
+	}
+	return w, opts, nil
+}
+
+// discardValue accepts an option without storing it.
+type discardValue struct {
+	isBool bool
+}
+
+func (v discardValue) String() string {
+	return ""
+}
+
+func (v discardValue) Set(string) error {
+	return nil
+}
+
+func (v discardValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// isBoolFlag reports whether the option of value is set without an argument.
+func isBoolFlag(value flag.Value) bool {
+	b, ok := value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// interrupted returns a channel that is closed when the user presses Ctrl+C.
+func interrupted() <-chan struct{} {
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+	return stop
+}
+
+func runWatch(args []string, stdout io.Writer, stderr io.Writer) int {
+	w, _, err := newWatcher("watch", args, stdout, stderr, nil)
+	if err != nil {
+		return usageError(err, "watch", stderr)
+	}
+	w.run(interrupted())
+	return exitOK
+}
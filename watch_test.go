@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshotChanges(t *testing.T) {
+	now := time.Now()
+	previous := snapshot{
+		"a.fdl": {size: 10, modTime: now},
+		"b.fdl": {size: 10, modTime: now},
+		"c.fdl": {size: 10, modTime: now},
+	}
+	current := snapshot{
+		"a.fdl": {size: 10, modTime: now},
+		"b.fdl": {size: 10, modTime: now.Add(time.Second)},
+		"d.fdl": {size: 5, modTime: now},
+	}
+	expected := []string{"b.fdl", "c.fdl", "d.fdl"}
+	if result := current.changes(previous); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+	if result := current.changes(current); len(result) != 0 {
+		t.Errorf("Expected no changes, got %v", result)
+	}
+}
+
+// syncBuffer is a bytes.Buffer that can be written by the watcher and read by the test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchRebuildsChangedDocuments(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+	if err := os.WriteFile("guide.fdl", []byte("@title Guide\nFirst version\n"), 0644); err != nil {
+		t.Fatalf("Could not create test file: %v", err)
+	}
+
+	var stdout, stderr syncBuffer
+	w := &watcher{
+		load:     func() (options, error) { return defaultOptions(), nil },
+		interval: 10 * time.Millisecond,
+		debounce: 10 * time.Millisecond,
+		stdout:   &stdout,
+		stderr:   &stderr,
+		command:  "watch",
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		w.run(stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	// waitFor wartet, bis check erfüllt ist, höchstens aber fünf Sekunden.
+	waitFor := func(description string, check func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !check(); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s, stdout: %s, stderr: %s", description, stdout.String(), stderr.String())
+			}
+		}
+	}
+	output := func() string {
+		content, _ := os.ReadFile(filepath.Join("documentation", "guide.html"))
+		return string(content)
+	}
+
+	waitFor("the first build", func() bool { return strings.Contains(output(), "First version") })
+	if err := os.WriteFile("guide.fdl", []byte("@title Guide\nSecond version\n@bogus\n"), 0644); err != nil {
+		t.Fatalf("Could not change test file: %v", err)
+	}
+	waitFor("the rebuild", func() bool { return strings.Contains(output(), "Second version") })
+	waitFor("the diagnostics", func() bool { return strings.Contains(stderr.String(), "@bogus") })
+	if !strings.Contains(stdout.String(), "changed: guide.fdl") {
+		t.Errorf("Expected the changed file to be reported, got %s", stdout.String())
+	}
+}
+
+func TestWatcherReadsExtraFlagsOnce(t *testing.T) {
+	inTempDir(t)
+	addr, verbose := "localhost:8080", false
+	w, opts, err := newWatcher("serve", []string{"--addr", ":9000", "--verbose", "--dir", "site"}, io.Discard, io.Discard, func(fs *flag.FlagSet) {
+		stringFlag(fs, &addr, "addr", "", "address")
+		boolFlag(fs, &verbose, "verbose", "", "verbose")
+	})
+	if err != nil {
+		t.Fatalf("newWatcher failed: %v", err)
+	}
+	if addr != ":9000" || !verbose || opts.Directory != "site" {
+		t.Fatalf("Expected the options to be parsed, got %q, %t, %q", addr, verbose, opts.Directory)
+	}
+
+	// Beim erneuten Einlesen bleiben die zusätzlichen Optionen unverändert.
+	addr, verbose = "unchanged", false
+	opts, err = w.load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if addr != "unchanged" || verbose || opts.Directory != "site" {
+		t.Errorf("Expected only the build options to be read again, got %q, %t, %q", addr, verbose, opts.Directory)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "sync"
+
+// forEach calls fn for every index 0 <= i < n on up to jobs goroutines and returns when
+// all calls have returned. fn must only write to data owned by index i, so the results
+// can be combined in index order afterwards and don't depend on the scheduling.
+func forEach(n int, jobs int, fn func(i int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	if jobs > n {
+		jobs = n
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	for _, jobs := range []int{0, 1, 3, 100} {
+		results := make([]int, 50)
+		var calls atomic.Int64
+		forEach(len(results), jobs, func(i int) {
+			calls.Add(1)
+			results[i] = i * i
+		})
+		if calls.Load() != 50 {
+			t.Errorf("jobs=%d: Expected 50 calls, got %d", jobs, calls.Load())
+		}
+		for i, result := range results {
+			if result != i*i {
+				t.Errorf("jobs=%d: Expected %d at %d, got %d", jobs, i*i, i, result)
+			}
+		}
+	}
+	forEach(0, 4, func(i int) {
+		t.Errorf("Expected no call without work, got %d", i)
+	})
+}
+
+func TestParallelBuildIsDeterministic(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(originalDir)
+	}()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change directory to temp dir: %v", err)
+	}
+	for i := 0; i < 30; i++ {
+		content := fmt.Sprintf("@title Chapter %d\n@section Part %d\n@ref chapter%02d.fdl#part-%d\n@warning unknown\n@bogus\n",
+			i, i, (i+1)%30, (i+1)%30)
+		if err := os.WriteFile(fmt.Sprintf("chapter%02d.fdl", i), []byte(content), 0644); err != nil {
+			t.Fatalf("Could not create test file: %v", err)
+		}
+	}
+
+	// Ein sequenzieller und ein paralleler Build müssen dieselben Dateien und Meldungen erzeugen.
+	var manifests, reports []string
+	for _, jobs := range []int{1, 8} {
+		opts := defaultOptions()
+		opts.Formats = []string{"html", "markdown", "pdf"}
+		opts.Jobs = jobs
+		opts.Rebuild = true
+		diags := processFiles(opts)
+		if diags.HasErrors() {
+			t.Fatalf("Expected no errors, got %v", diags.Sorted())
+		}
+		content, err := os.ReadFile(filepath.Join("documentation", manifestFile))
+		if err != nil {
+			t.Fatalf("Failed to read the manifest: %v", err)
+		}
+		manifests = append(manifests, string(content))
+		reports = append(reports, fmt.Sprint(diags.Sorted()))
+	}
+	if manifests[0] != manifests[1] {
+		t.Errorf("Expected the same manifest, got\n%s\nand\n%s", manifests[0], manifests[1])
+	}
+	if reports[0] != reports[1] {
+		t.Errorf("Expected the same diagnostics in the same order, got\n%s\nand\n%s", reports[0], reports[1])
+	}
+}